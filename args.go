@@ -0,0 +1,316 @@
+// Copyright (C) 2023 Michael J. Fromberger. All Rights Reserved.
+
+package flax
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Parse constructs a new value of type T, which must be a struct type with
+// flaggable fields as described by [Check], and binds its fields to a new
+// flag set that reports errors without exiting. It then parses args as
+// [Fields.Parse] and returns the populated value along with the remaining
+// non-flag arguments. If args contains -h or -help and T does not define a
+// flag by that name, Parse returns [flag.ErrHelp].
+//
+// The usage message of the flag set lists the flags as [Fields.PrintDefaults]
+// does. By default, the flag set writes usage and error messages to
+// [os.Stderr]; use the [ParseOutput] option to change this. Use the
+// [EnvPrefix] option to bind the fields of T to environment variables.
+func Parse[T any](args []string, opts ...ParseOption) (*T, []string, error) {
+	v := new(T)
+	fields, err := Check(v)
+	if err != nil {
+		return nil, nil, err
+	}
+	fs := flag.NewFlagSet(filepath.Base(os.Args[0]), flag.ContinueOnError)
+	fields.Bind(fs)
+	fields.setUsage(fs)
+	if err := fields.Parse(fs, args, opts...); err != nil {
+		return nil, nil, err
+	}
+	return v, fs.Args(), nil
+}
+
+// Parse parses args using fs, to which f must already be bound. A field of f
+// that has not been bound to any flag set is instead associated with the flag
+// of the same name in fs, if any, so that f may describe flags bound by other
+// means (see [NewFields]). After the arguments have been parsed, each field
+// of f bound to an environment variable (see [Fields.BindEnv]) whose flag was
+// not set by args is set from the value of that variable, if it is set and
+// non-empty. Thus flags set on the command line take priority over the
+// environment. Finally, Parse checks the relations among flags declared by
+// the xor, oneof, and requires tag options (see [Fields.CheckGroups]) and
+// calls any validation hooks (see [Fields.Validate]), and reports all the
+// errors from both.
+//
+// The behaviour of Parse can be modified by options; see [ParseOption].
+func (f Fields) Parse(fs *flag.FlagSet, args []string, opts ...ParseOption) error {
+	po := newParseOptions(opts)
+	f.adopt(fs)
+	if po.output != nil {
+		fs.SetOutput(po.output)
+	}
+	if po.bindEnv {
+		f.BindEnv(po.envPrefix)
+	}
+	if po.argsEnv != "" {
+		if err := po.parseEnvArgs(fs); err != nil {
+			return err
+		}
+	}
+	if po.expandFiles {
+		exp, err := ExpandArgs(args)
+		if err != nil {
+			return err
+		}
+		args = exp
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := f.parseEnv(fs); err != nil {
+		return err
+	}
+	return errors.Join(f.CheckGroups(), f.Validate())
+}
+
+// A ParseOption customizes the behaviour of [Fields.Parse].
+type ParseOption func(*parseOptions)
+
+type parseOptions struct {
+	expandFiles bool      // expand @file arguments
+	argsEnv     string    // environment variable holding extra arguments
+	output      io.Writer // output for usage and error messages
+	bindEnv     bool      // bind fields to the environment
+	envPrefix   string    // prefix for environment bindings
+}
+
+func newParseOptions(opts []ParseOption) *parseOptions {
+	po := new(parseOptions)
+	for _, opt := range opts {
+		opt(po)
+	}
+	return po
+}
+
+// ExpandArgFiles returns a [ParseOption] that expands "@path" arguments as
+// described by [ExpandArgs] before the arguments are parsed.
+func ExpandArgFiles() ParseOption {
+	return func(po *parseOptions) { po.expandFiles = true }
+}
+
+// ParseOutput returns a [ParseOption] that directs the usage and error
+// messages of the flag set being parsed to w. Use [io.Discard] to suppress
+// them.
+func ParseOutput(w io.Writer) ParseOption {
+	return func(po *parseOptions) { po.output = w }
+}
+
+// EnvPrefix returns a [ParseOption] that binds the fields to environment
+// variables with the given prefix, as [Fields.BindEnv] does, before the
+// arguments are parsed.
+func EnvPrefix(prefix string) ParseOption {
+	return func(po *parseOptions) { po.bindEnv, po.envPrefix = true, prefix }
+}
+
+// ArgsFromEnv returns a [ParseOption] that reads additional arguments from the
+// named environment variable, and parses them before the arguments given to
+// [Fields.Parse], as if they had been prepended to them.
+//
+// The value of the variable is split into arguments using the quoting rules
+// of the POSIX shell (see [ExpandArgs]). The arguments from the variable must
+// all be flags; a non-flag argument is an error. Errors in the arguments from
+// the variable are reported with the name of the variable and the arguments
+// it contained. If the variable is unset or empty, this option has no effect.
+func ArgsFromEnv(name string) ParseOption {
+	return func(po *parseOptions) { po.argsEnv = name }
+}
+
+// parseEnvArgs parses the arguments in the environment variable named by the
+// argsEnv option into fs.
+func (po *parseOptions) parseEnvArgs(fs *flag.FlagSet) error {
+	val := os.Getenv(po.argsEnv)
+	if val == "" {
+		return nil
+	}
+	args, err := splitShell(val)
+	if err != nil {
+		return fmt.Errorf("environment variable %s: %w", po.argsEnv, err)
+	}
+	if po.expandFiles {
+		args, err = ExpandArgs(args)
+		if err != nil {
+			return fmt.Errorf("environment variable %s: %w", po.argsEnv, err)
+		}
+	}
+	if err := fs.Parse(args); err != nil {
+		return fmt.Errorf("environment variable %s (arguments %q): %w", po.argsEnv, args, err)
+	}
+	if fs.NArg() != 0 {
+		return fmt.Errorf("environment variable %s: unexpected non-flag argument %q",
+			po.argsEnv, fs.Arg(0))
+	}
+	return nil
+}
+
+// maxArgFileDepth is the maximum nesting depth of argument files.
+const maxArgFileDepth = 16
+
+// ExpandArgs returns a copy of args in which each argument of the form
+// "@path" is replaced by the arguments listed in the named file.
+//
+// Each line of the file is split into arguments using the quoting rules of
+// the POSIX shell, so that a line may contain multiple arguments, and an
+// argument containing spaces must be quoted. Blank lines and lines whose
+// first non-space character is "#" are ignored. Arguments read from a file
+// are themselves expanded, so files may include other files, up to a fixed
+// depth limit. A file that includes itself, directly or indirectly, is an
+// error. Relative paths are resolved with respect to the current working
+// directory.
+//
+// To pass an argument that begins with "@" without expansion, double the "@".
+// An argument "--" ends expansion, and all arguments after it are returned
+// unmodified.
+func ExpandArgs(args []string) ([]string, error) {
+	var e argExpander
+	for _, arg := range args {
+		if err := e.add(arg); err != nil {
+			return nil, err
+		}
+	}
+	return e.out, nil
+}
+
+type argExpander struct {
+	out   []string // expanded arguments
+	stack []string // files being expanded, outermost first
+	done  bool     // "--" was seen; do not expand further arguments
+}
+
+func (e *argExpander) add(arg string) error {
+	switch {
+	case e.done:
+		// pass unmodified
+	case arg == "--":
+		e.done = true
+	case strings.HasPrefix(arg, "@@"):
+		arg = arg[1:] // unescape leading "@"
+	case len(arg) > 1 && arg[0] == '@':
+		return e.expandFile(arg[1:])
+	}
+	e.out = append(e.out, arg)
+	return nil
+}
+
+func (e *argExpander) expandFile(path string) error {
+	if len(e.stack) >= maxArgFileDepth {
+		return fmt.Errorf("%s: argument files nested too deeply", path)
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+	for i, p := range e.stack {
+		if p == abs {
+			return fmt.Errorf("%s: argument file cycle: %s", path,
+				strings.Join(append(e.stack[i:], abs), " -> "))
+		}
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	e.stack = append(e.stack, abs)
+	defer func() { e.stack = e.stack[:len(e.stack)-1] }()
+
+	sc := bufio.NewScanner(f)
+	for line := 1; sc.Scan(); line++ {
+		text := strings.TrimSpace(sc.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		args, err := splitShell(text)
+		if err != nil {
+			return fmt.Errorf("%s:%d: %w", path, line, err)
+		}
+		for _, arg := range args {
+			if err := e.add(arg); err != nil {
+				return fmt.Errorf("%s:%d: %w", path, line, err)
+			}
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return nil
+}
+
+// splitShell splits s into words using the quoting rules of the POSIX shell.
+// Words are separated by unquoted whitespace. Within single quotes all
+// characters are literal. Within double quotes, a backslash escapes a
+// following backslash, double quote, dollar sign, or backquote. Outside
+// quotes, a backslash escapes any following character. No expansions are
+// performed.
+func splitShell(s string) ([]string, error) {
+	var words []string
+	var cur strings.Builder
+	inWord := false
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; c {
+		case ' ', '\t', '\n', '\r':
+			if inWord {
+				words = append(words, cur.String())
+				cur.Reset()
+				inWord = false
+			}
+
+		case '\\':
+			inWord = true
+			if i+1 >= len(s) {
+				return nil, errors.New("trailing backslash")
+			}
+			i++
+			cur.WriteByte(s[i])
+
+		case '\'':
+			inWord = true
+			end := strings.IndexByte(s[i+1:], '\'')
+			if end < 0 {
+				return nil, errors.New("unterminated single quote")
+			}
+			cur.WriteString(s[i+1 : i+1+end])
+			i += end + 1
+
+		case '"':
+			inWord = true
+			for i++; ; i++ {
+				if i >= len(s) {
+					return nil, errors.New("unterminated double quote")
+				} else if s[i] == '"' {
+					break
+				} else if s[i] == '\\' && i+1 < len(s) && strings.IndexByte("\\\"$`", s[i+1]) >= 0 {
+					i++
+				}
+				cur.WriteByte(s[i])
+			}
+
+		default:
+			inWord = true
+			cur.WriteByte(c)
+		}
+	}
+	if inWord {
+		words = append(words, cur.String())
+	}
+	return words, nil
+}
@@ -0,0 +1,238 @@
+// Copyright (C) 2023 Michael J. Fromberger. All Rights Reserved.
+
+package flax_test
+
+import (
+	"errors"
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/creachadair/flax"
+)
+
+// writeFiles writes the specified files into dir and changes to that
+// directory for the duration of the test.
+func writeFiles(t *testing.T, files map[string]string) {
+	t.Helper()
+	dir := t.TempDir()
+	for name, text := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(text), 0644); err != nil {
+			t.Fatalf("Write %q: %v", name, err)
+		}
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Getwd: %v", err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatalf("Chdir: %v", err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+}
+
+func TestExpandArgs(t *testing.T) {
+	writeFiles(t, map[string]string{
+		"basic":  "-a\n-b=1\n",
+		"quoted": "# A comment\n\n  -name 'two words' \"it's\" back\\ slash\n",
+		"nested": "-x\n@basic\n-y\n",
+		"dash":   "-p\n--\n@basic\n",
+		"cycle1": "@cycle2\n",
+		"cycle2": "-q @cycle1\n",
+		"self":   "@self\n",
+		"badq":   "-ok\n'unterminated\n",
+		"inner":  "@badq\n",
+	})
+
+	tests := []struct {
+		input []string
+		want  []string
+		err   string
+	}{
+		{nil, nil, ""},
+		{[]string{"-a", "b"}, []string{"-a", "b"}, ""},
+		{[]string{"@basic", "z"}, []string{"-a", "-b=1", "z"}, ""},
+		{[]string{"@quoted"}, []string{"-name", "two words", "it's", "back slash"}, ""},
+		{[]string{"@nested"}, []string{"-x", "-a", "-b=1", "-y"}, ""},
+		{[]string{"@@basic", "@"}, []string{"@basic", "@"}, ""},
+		{[]string{"@dash", "@basic"}, []string{"-p", "--", "@basic", "@basic"}, ""},
+		{[]string{"--", "@basic"}, []string{"--", "@basic"}, ""},
+
+		{[]string{"@nonesuch"}, nil, "nonesuch"},
+		{[]string{"@cycle1"}, nil, "cycle"},
+		{[]string{"@self"}, nil, "cycle"},
+		{[]string{"@badq"}, nil, "badq:2: unterminated single quote"},
+		{[]string{"@inner"}, nil, "inner:1: badq:2:"},
+	}
+	for _, tc := range tests {
+		got, err := flax.ExpandArgs(tc.input)
+		if tc.err != "" {
+			if err == nil {
+				t.Errorf("ExpandArgs(%q): got %q, want error", tc.input, got)
+			} else if !strings.Contains(err.Error(), tc.err) {
+				t.Errorf("ExpandArgs(%q): got error %v, want %q", tc.input, err, tc.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ExpandArgs(%q): unexpected error: %v", tc.input, err)
+		} else if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("ExpandArgs(%q):\ngot:  %q\nwant: %q", tc.input, got, tc.want)
+		}
+	}
+}
+
+func TestExpandArgsDepth(t *testing.T) {
+	files := make(map[string]string)
+	for i := 0; i < 20; i++ {
+		files["f"+string(rune('a'+i))] = "@f" + string(rune('a'+i+1)) + "\n"
+	}
+	writeFiles(t, files)
+	if got, err := flax.ExpandArgs([]string{"@fa"}); err == nil {
+		t.Errorf("ExpandArgs: got %q, want error", got)
+	} else if !strings.Contains(err.Error(), "too deeply") {
+		t.Errorf("ExpandArgs: got %v, want depth error", err)
+	}
+}
+
+func TestParseArgFiles(t *testing.T) {
+	writeFiles(t, map[string]string{
+		"flags": "-name 'a b'\n-count=3\n",
+	})
+	var flags struct {
+		Name  string `flag:"name,Name"`
+		Count int    `flag:"count,Count"`
+	}
+	fi := flax.MustCheck(&flags)
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fi.Bind(fs)
+	if err := fi.Parse(fs, []string{"@flags", "rest"}, flax.ExpandArgFiles()); err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if flags.Name != "a b" || flags.Count != 3 {
+		t.Errorf("Flags: got %+v, want {a b 3}", flags)
+	}
+	if got := fs.Args(); !reflect.DeepEqual(got, []string{"rest"}) {
+		t.Errorf("Args: got %q, want [rest]", got)
+	}
+}
+
+func TestArgsFromEnv(t *testing.T) {
+	var flags struct {
+		Verbose bool   `flag:"v,Verbose"`
+		Name    string `flag:"name,Name"`
+		Count   int    `flag:"count,Count"`
+	}
+	newFlags := func() (flax.Fields, *flag.FlagSet) {
+		fi := flax.MustCheck(&flags)
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		fs.SetOutput(io.Discard)
+		fi.Bind(fs)
+		return fi, fs
+	}
+
+	t.Run("OK", func(t *testing.T) {
+		t.Setenv("MYTOOL_FLAGS", `-v -name "from env" -count=1`)
+		fi, fs := newFlags()
+		if err := fi.Parse(fs, []string{"-count", "2", "arg"}, flax.ArgsFromEnv("MYTOOL_FLAGS")); err != nil {
+			t.Fatalf("Parse failed: %v", err)
+		}
+		if !flags.Verbose || flags.Name != "from env" || flags.Count != 2 {
+			t.Errorf("Flags: got %+v, want {true from env 2}", flags)
+		}
+		if got := fs.Args(); !reflect.DeepEqual(got, []string{"arg"}) {
+			t.Errorf("Args: got %q, want [arg]", got)
+		}
+	})
+
+	t.Run("Unset", func(t *testing.T) {
+		t.Setenv("MYTOOL_FLAGS", "")
+		fi, fs := newFlags()
+		if err := fi.Parse(fs, []string{"-name", "x"}, flax.ArgsFromEnv("MYTOOL_FLAGS")); err != nil {
+			t.Fatalf("Parse failed: %v", err)
+		}
+	})
+
+	for _, tc := range []struct {
+		value, want string
+	}{
+		{`-v -bogus`, `MYTOOL_FLAGS (arguments ["-v" "-bogus"])`},
+		{`-count=x`, `MYTOOL_FLAGS`},
+		{`-v extra`, `non-flag argument "extra"`},
+		{`-name 'open`, `MYTOOL_FLAGS: unterminated single quote`},
+	} {
+		t.Run("Error", func(t *testing.T) {
+			t.Setenv("MYTOOL_FLAGS", tc.value)
+			fi, fs := newFlags()
+			err := fi.Parse(fs, nil, flax.ArgsFromEnv("MYTOOL_FLAGS"))
+			if err == nil {
+				t.Fatalf("Parse %q: got nil, want error", tc.value)
+			} else if !strings.Contains(err.Error(), tc.want) {
+				t.Errorf("Parse %q: got error %v, want %q", tc.value, err, tc.want)
+			}
+		})
+	}
+}
+
+func TestParseGeneric(t *testing.T) {
+	type options struct {
+		Verbose bool   `flag:"v,Verbose"`
+		Name    string `flag:"name,default=anon,Name"`
+		Level   int    `flag:"level,env=TEST_PARSE_LEVEL,Level"`
+	}
+	t.Setenv("TEST_PARSE_LEVEL", "4")
+
+	v, rest, err := flax.Parse[options]([]string{"-v", "x", "-y"}, flax.ParseOutput(io.Discard))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if want := (options{Verbose: true, Name: "anon", Level: 4}); *v != want {
+		t.Errorf("Parse: got %+v, want %+v", *v, want)
+	}
+	if want := []string{"x", "-y"}; !reflect.DeepEqual(rest, want) {
+		t.Errorf("Parse rest: got %q, want %q", rest, want)
+	}
+
+	var out strings.Builder
+	if v, _, err := flax.Parse[options]([]string{"-bogus"}, flax.ParseOutput(&out)); err == nil {
+		t.Errorf("Parse: got %+v, want error", v)
+	} else if !strings.Contains(out.String(), "-bogus") {
+		t.Errorf("Parse output: got %q, want mention of -bogus", out.String())
+	}
+	if _, _, err := flax.Parse[options]([]string{"-h"}, flax.ParseOutput(io.Discard)); !errors.Is(err, flag.ErrHelp) {
+		t.Errorf("Parse -h: got %v, want %v", err, flag.ErrHelp)
+	}
+	if v, _, err := flax.Parse[struct{ X int }](nil); err == nil {
+		t.Errorf("Parse: got %+v, want error", v)
+	}
+}
+
+func TestEnvPrefix(t *testing.T) {
+	type options struct {
+		Name  string `flag:"name,default=anon,Name"`
+		Level int    `flag:"level,Level"`
+	}
+	t.Setenv("TEST_PREFIX_NAME", "env")
+	t.Setenv("TEST_PREFIX_LEVEL", "3")
+
+	v, _, err := flax.Parse[options]([]string{"-level", "5"}, flax.EnvPrefix("TEST_PREFIX"))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if want := (options{Name: "env", Level: 5}); *v != want {
+		t.Errorf("Parse: got %+v, want %+v", *v, want)
+	}
+
+	// Without the option, the environment is not consulted.
+	v, _, err = flax.Parse[options](nil)
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if want := (options{Name: "anon"}); *v != want {
+		t.Errorf("Parse: got %+v, want %+v", *v, want)
+	}
+}
@@ -0,0 +1,64 @@
+// Copyright (C) 2023 Michael J. Fromberger. All Rights Reserved.
+
+package flax
+
+import (
+	"reflect"
+	"sync"
+)
+
+// typeCache maps struct types to their *typeInfo. The type-level analysis of a
+// struct (which fields are flaggable, and their parsed tags) does not depend
+// on the value being checked, so it is computed once per type and shared by
+// all subsequent calls to Check. Only the parts that depend on the value
+// (such as "*" defaults and environment lookups) are recomputed.
+var typeCache sync.Map
+
+// A typeInfo records the type-level analysis of a struct type.
+type typeInfo struct {
+	fields []fieldSpec // flaggable fields, in declaration order
+	errs   []tagError  // errors from parsing field tags, in declaration order
+}
+
+// A tagError is an error from parsing the tags of the struct field at index.
+type tagError struct {
+	index int
+	err   *FieldError
+}
+
+// A fieldSpec pairs a flaggable struct field with its parsed tags.  The spec
+// is shared by all values of the type and must not be modified.
+type fieldSpec struct {
+	field reflect.StructField
+	spec  FieldSpec
+}
+
+// checkType returns the type-level analysis of the struct type rt.
+func checkType(rt reflect.Type) *typeInfo {
+	if v, ok := typeCache.Load(rt); ok {
+		return v.(*typeInfo)
+	}
+	ti := new(typeInfo)
+	for i := 0; i < rt.NumField(); i++ {
+		ft := rt.Field(i)
+		if !ft.IsExported() {
+			continue // unexported fields are not considered
+		}
+		spec, ok, err := ParseTag(ft.Tag)
+		if !ok {
+			continue // un-flagged fields are not considered
+		} else if err != nil {
+			ti.errs = append(ti.errs, tagError{index: i, err: &FieldError{
+				Field: ft.Name,
+				Tag:   string(ft.Tag),
+				Kind:  KindTag,
+				Err:   err,
+			}})
+			continue
+		}
+		spec.Field = ft.Name
+		ti.fields = append(ti.fields, fieldSpec{field: ft, spec: spec})
+	}
+	v, _ := typeCache.LoadOrStore(rt, ti)
+	return v.(*typeInfo)
+}
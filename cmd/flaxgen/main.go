@@ -0,0 +1,283 @@
+// Copyright (C) 2023 Michael J. Fromberger. All Rights Reserved.
+
+// Program flaxgen generates flag binding code for struct types with flag tags,
+// so that flags can be bound without inspecting struct types at runtime.
+//
+// Usage:
+//
+//	//go:generate go run github.com/creachadair/flax/cmd/flaxgen [-type T,...] [-output file]
+//
+// Flaxgen reads the Go package in the current directory (or the directory
+// named as its argument), and for each struct type that has at least one
+// field with a flag tag, generates two methods on its pointer type:
+//
+//	// FlagFields returns flag metadata for the fields of v.
+//	func (v *T) FlagFields() (flax.Fields, error)
+//
+//	// BindFlags binds the flags of v to fs.
+//	func (v *T) BindFlags(fs *flag.FlagSet)
+//
+// The name of the BindFlags method can be changed with the -bind flag.
+//
+// BindFlags binds a field of one of the types built into the flag package
+// (bool, float64, int, int64, string, uint, uint64, or time.Duration) by
+// calling the corresponding method of the flag set directly, with its default
+// value resolved at generation time, unless its tag uses options that need
+// run-time support from flax, such as env or count, or takes its default from
+// the environment. Other fields are bound using [flax.NewField]. If every
+// field is bound directly, BindFlags does not inspect types at run time.
+//
+// FlagFields constructs metadata for all the fields with [flax.NewFields], for
+// use with functions such as [flax.Fields.Parse] that check the relations and
+// validation hooks of the fields. The metadata is not bound to flags itself;
+// when it is parsed with the flag set passed to BindFlags, each field reports
+// the state of the flag of its name:
+//
+//	v.BindFlags(fs)
+//	fields, err := v.FlagFields()
+//	...
+//	err = fields.Parse(fs, os.Args[1:])
+//
+// The flag tags are checked at generation time using the same rules as
+// [flax.Check], including whether each field has a compatible type and
+// whether its default value is valid. Any problems are reported as errors
+// with file positions, and no output is written. A field of a named type that
+// is not flag compatible is reported as a warning instead, since the program
+// may register its type with flax at run time; list such types with the
+// -registered flag, as for flaxlint, to silence the warning. Since the
+// generated code records the tags as they were at generation time, it must be
+// regenerated when the tags change.
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"go/format"
+	"go/token"
+	"log"
+	"maps"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/creachadair/flax"
+	"github.com/creachadair/flax/internal/tagcheck"
+)
+
+var flags struct {
+	Output string `flag:"output,default=flax_gen.go,Output file name, relative to the package directory"`
+	Types  string `flag:"type,Comma-separated names of types to generate (default all with flag tags)"`
+	Bind   string `flag:"bind,default=BindFlags,Name of the generated method that binds flags"`
+	Reg    string `flag:"registered,Comma-separated names of types registered at run time"`
+}
+
+func init() {
+	flax.MustBind(flag.CommandLine, &flags)
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s [options] [package-dir]\n\nOptions:\n", filepath.Base(os.Args[0]))
+		flag.PrintDefaults()
+	}
+}
+
+func main() {
+	flag.Parse()
+	dir := "."
+	if flag.NArg() > 1 {
+		log.Fatal("At most one package directory may be given")
+	} else if flag.NArg() == 1 {
+		dir = flag.Arg(0)
+	}
+	if !token.IsIdentifier(flags.Bind) || flags.Bind == "FlagFields" {
+		log.Fatalf("Invalid bind method name %q", flags.Bind)
+	}
+	var types, registered []string
+	if flags.Types != "" {
+		types = strings.Split(flags.Types, ",")
+	}
+	if flags.Reg != "" {
+		registered = strings.Split(flags.Reg, ",")
+	}
+
+	out := filepath.Join(dir, flags.Output)
+	code, err := generate(dir, filepath.Base(out), flags.Bind, types, registered)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := os.WriteFile(out, code, 0644); err != nil {
+		log.Fatalf("Write output: %v", err)
+	}
+}
+
+// generate reads the package in dir, ignoring test files and the file named
+// skip, and returns generated code for its flag structs, with bind methods
+// named bind. If names is non-empty, only the named types are considered, and
+// each must exist. The registered types are passed to [tagcheck.Load].
+// Warnings are logged, and do not prevent generation.
+func generate(dir, skip, bind string, names, registered []string) ([]byte, error) {
+	pkg, diags, err := tagcheck.Load(dir, &tagcheck.Config{
+		Skip:       func(name string) bool { return name == skip },
+		Registered: registered,
+	})
+	if err != nil {
+		return nil, err
+	}
+	var errs []error
+	for _, d := range diags {
+		if len(names) != 0 && !slices.Contains(names, d.Struct) {
+			continue
+		} else if d.Warning {
+			log.Print(d)
+		} else {
+			errs = append(errs, d)
+		}
+	}
+	var structs []*tagcheck.Struct
+	for _, s := range pkg.Structs {
+		if len(names) == 0 || slices.Contains(names, s.Name) {
+			structs = append(structs, s)
+		}
+	}
+	for _, name := range names {
+		if !slices.ContainsFunc(structs, func(s *tagcheck.Struct) bool { return s.Name == name }) {
+			errs = append(errs, fmt.Errorf("type %q not found or has no flag fields", name))
+		}
+	}
+	if len(errs) != 0 {
+		return nil, errors.Join(errs...)
+	} else if len(structs) == 0 {
+		return nil, errors.New("no struct types with flag fields found")
+	}
+	return emit(pkg.Name, bind, structs)
+}
+
+// emit generates formatted source code for the given structs, with bind
+// methods named bind.
+func emit(pkgName, bind string, structs []*tagcheck.Struct) ([]byte, error) {
+	var body bytes.Buffer
+	var usesTime bool
+	for _, si := range structs {
+		fmt.Fprintf(&body, `
+// FlagFields returns flag metadata for the fields of v.
+func (v *%s) FlagFields() (flax.Fields, error) {
+	return flax.NewFields(v,
+`, si.Name)
+		for _, f := range si.Fields {
+			fmt.Fprintf(&body, "\t\t%s,\n", specLiteral(f.Spec))
+		}
+		fmt.Fprintf(&body, `	)
+}
+
+// %[2]s binds the flags of v to fs. It panics if the flags are not valid.
+func (v *%[1]s) %[2]s(fs *flag.FlagSet) {
+`, si.Name, bind)
+		for _, f := range si.Fields {
+			spec := f.Spec
+			if method, dvalue, ok := directBind(f); ok {
+				fmt.Fprintf(&body, "\tfs.%s(&v.%s, %q, %s, %q)\n", method, spec.Field, spec.Name, dvalue, spec.Usage)
+				usesTime = usesTime || strings.Contains(dvalue, "time.")
+				continue
+			}
+			// Relations among the flags do not affect binding.
+			spec.Options = maps.Clone(spec.Options)
+			for _, key := range []string{"xor", "oneof", "requires"} {
+				delete(spec.Options, key)
+			}
+			fmt.Fprintf(&body, `	if fi, err := flax.NewField(&v.%s, %s); err != nil {
+		panic("bind flag -%s: " + err.Error())
+	} else {
+		fi.Bind(fs)
+	}
+`, spec.Field, specLiteral(spec), spec.Name)
+		}
+		body.WriteString("}\n")
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "// Code generated by flaxgen. DO NOT EDIT.\n\npackage %s\n\nimport (\n\t\"flag\"\n", pkgName)
+	if usesTime {
+		buf.WriteString("\t\"time\"\n")
+	}
+	buf.WriteString("\n\t\"github.com/creachadair/flax\"\n)\n")
+	buf.Write(body.Bytes())
+	return format.Source(buf.Bytes())
+}
+
+// specLiteral renders spec as a Go composite literal.
+func specLiteral(spec flax.FieldSpec) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "flax.FieldSpec{Field: %q, Name: %q, Usage: %q", spec.Field, spec.Name, spec.Usage)
+	if len(spec.Options) != 0 {
+		sb.WriteString(", Options: map[string]string{")
+		for i, key := range slices.Sorted(maps.Keys(spec.Options)) {
+			if i > 0 {
+				sb.WriteString(", ")
+			}
+			fmt.Fprintf(&sb, "%q: %q", key, spec.Options[key])
+		}
+		sb.WriteString("}")
+	}
+	sb.WriteString("}")
+	return sb.String()
+}
+
+// directOptions are the tag options that do not affect how a flag is bound.
+var directOptions = []string{"default", "mutable", "oneof", "reloadable", "requires", "secret", "xor"}
+
+// directBind reports whether f can be bound by calling a method of the flag
+// set directly, and if so returns the name of the method and the Go source
+// for the default value of the flag.
+func directBind(f *tagcheck.Field) (method, dvalue string, ok bool) {
+	for key := range f.Spec.Options {
+		if !slices.Contains(directOptions, key) {
+			return "", "", false
+		}
+	}
+	switch v := f.Default.(type) {
+	case bool:
+		return "BoolVar", strconv.FormatBool(v), true
+	case float64:
+		if math.IsInf(v, 0) || math.IsNaN(v) {
+			return "", "", false
+		}
+		return "Float64Var", strconv.FormatFloat(v, 'g', -1, 64), true
+	case int:
+		return "IntVar", strconv.Itoa(v), true
+	case int64:
+		return "Int64Var", strconv.FormatInt(v, 10), true
+	case string:
+		return "StringVar", strconv.Quote(v), true
+	case uint:
+		return "UintVar", strconv.FormatUint(uint64(v), 10), true
+	case uint64:
+		return "Uint64Var", strconv.FormatUint(v, 10), true
+	case time.Duration:
+		return "DurationVar", durationLiteral(v), true
+	}
+	return "", "", false
+}
+
+// durationLiteral renders d as a Go expression using the largest unit of the
+// time package that represents it exactly.
+func durationLiteral(d time.Duration) string {
+	if d == 0 {
+		return "0"
+	}
+	for _, u := range []struct {
+		name string
+		unit time.Duration
+	}{
+		{"Hour", time.Hour}, {"Minute", time.Minute}, {"Second", time.Second},
+		{"Millisecond", time.Millisecond}, {"Microsecond", time.Microsecond},
+	} {
+		if d%u.unit == 0 {
+			return fmt.Sprintf("%d * time.%s", d/u.unit, u.name)
+		}
+	}
+	return fmt.Sprintf("%d * time.Nanosecond", d)
+}
@@ -0,0 +1,170 @@
+// Copyright (C) 2023 Michael J. Fromberger. All Rights Reserved.
+
+package main
+
+import (
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/creachadair/flax/flaxtest"
+)
+
+func TestGenerate(t *testing.T) {
+	code, err := generate("testdata/opts", "flax_gen.go", "BindFlags", nil, nil)
+	if err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+	flaxtest.CheckGolden(t, string(code), "testdata/opts.golden")
+}
+
+func TestGeneratedCode(t *testing.T) {
+	// The generated code must compile together with the package it was
+	// generated for.
+	fset := token.NewFileSet()
+	var files []*ast.File
+	for _, path := range []string{"testdata/opts/opts.go", "testdata/opts.golden"} {
+		f, err := parser.ParseFile(fset, path, nil, 0)
+		if err != nil {
+			t.Fatalf("Parse: %v", err)
+		}
+		files = append(files, f)
+	}
+	conf := types.Config{Importer: importer.ForCompiler(fset, "source", nil)}
+	if _, err := conf.Check("opts", fset, files, nil); err != nil {
+		t.Errorf("Type check generated code: %v", err)
+	}
+}
+
+func TestRunGenerated(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping build of generated code in short mode")
+	}
+	root, err := filepath.Abs("../..")
+	if err != nil {
+		t.Fatalf("Abs: %v", err)
+	}
+	code, err := generate("testdata/opts", "flax_gen.go", "BindFlags", nil, nil)
+	if err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+	src, err := os.ReadFile("testdata/opts/opts.go")
+	if err != nil {
+		t.Fatalf("Read input: %v", err)
+	}
+
+	// Build a module holding the input package, the generated code, and a test
+	// that parses flags with them, using the flax package in this repository.
+	dir := t.TempDir()
+	for name, text := range map[string]string{
+		"go.mod": "module example.com/opts\n\ngo 1.23\n\n" +
+			"require github.com/creachadair/flax v0.0.0\n\n" +
+			"replace github.com/creachadair/flax => " + root + "\n",
+		"opts.go":      string(src),
+		"flax_gen.go":  string(code),
+		"opts_test.go": generatedTest,
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(text), 0644); err != nil {
+			t.Fatalf("Write %s: %v", name, err)
+		}
+	}
+	cmd := exec.Command("go", "test", "-count=1", ".")
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GOWORK=off", "GOFLAGS=-mod=mod")
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Errorf("Test generated code: %v\n%s", err, out)
+	}
+}
+
+// generatedTest is a test run against the code generated for testdata/opts.
+const generatedTest = `package opts
+
+import (
+	"flag"
+	"io"
+	"slices"
+	"testing"
+)
+
+func TestGenerated(t *testing.T) {
+	for _, tc := range []struct {
+		args    []string
+		wantErr string
+		set     []string
+	}{
+		{nil, "", nil},
+		{[]string{"-limit", "1m"}, "flag -limit requires -input", []string{"limit"}},
+		{[]string{"-input", "x", "-limit", "1m", "-tag", "a"}, "", []string{"input", "tag", "limit"}},
+	} {
+		var v Options
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		fs.SetOutput(io.Discard)
+		v.BindFlags(fs)
+		fields, err := v.FlagFields()
+		if err != nil {
+			t.Fatalf("FlagFields: %v", err)
+		}
+		err = fields.Parse(fs, tc.args)
+		if tc.wantErr == "" && err != nil {
+			t.Errorf("Parse %q: unexpected error: %v", tc.args, err)
+		} else if tc.wantErr != "" && (err == nil || err.Error() != tc.wantErr) {
+			t.Errorf("Parse %q: got %v, want %q", tc.args, err, tc.wantErr)
+		}
+		var set []string
+		for _, fi := range fields.Changed() {
+			set = append(set, fi.Name)
+		}
+		if !slices.Equal(set, tc.set) {
+			t.Errorf("Parse %q: changed flags %q, want %q", tc.args, set, tc.set)
+		}
+	}
+}
+`
+
+func TestGenerateTypes(t *testing.T) {
+	if _, err := generate("testdata/opts", "flax_gen.go", "BindFlags", []string{"Options"}, nil); err != nil {
+		t.Errorf("Generate Options: unexpected error: %v", err)
+	}
+	if _, err := generate("testdata/opts", "flax_gen.go", "BindFlags", []string{"NoFlags"}, nil); err == nil {
+		t.Error("Generate NoFlags: got nil, want error")
+	}
+}
+
+func TestGenerateRegistered(t *testing.T) {
+	for _, reg := range [][]string{nil, {"Color"}} {
+		code, err := generate("testdata/custom", "flax_gen.go", "BindFlags", nil, reg)
+		if err != nil {
+			t.Fatalf("Generate %q: unexpected error: %v", reg, err)
+		}
+		if want := "flax.NewField(&v.Fill,"; !strings.Contains(string(code), want) {
+			t.Errorf("Generate %q: output does not contain %q:\n%s", reg, want, code)
+		}
+	}
+}
+
+func TestGenerateErrors(t *testing.T) {
+	_, err := generate("testdata/bad", "flax_gen.go", "BindFlags", nil, nil)
+	if err == nil {
+		t.Fatal("Generate: got nil, want error")
+	}
+	for _, want := range []string{
+		`bad.go:5:2: Bad: field "NoUsage": invalid flag tag format`,
+		`bad.go:6:2: Bad: field "BadType": type []byte is not flag compatible`,
+		`bad.go:7:2: Bad: field "BadInt": invalid default`,
+		`bad.go:8:2: Bad: field "BothDef": default tag and string are both set`,
+		`bad.go:9:2: Bad: field "DupName": duplicate flag name "int"`,
+		`bad.go:10:2: Bad: field "Quotes": invalid default format`,
+		`bad.go:11:2: Bad: field "Needs": requires unknown flag "nope"`,
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Error does not contain %q:\n%v", want, err)
+		}
+	}
+}
@@ -0,0 +1,12 @@
+// Package bad is a test input for flaxgen with invalid tags.
+package bad
+
+type Bad struct {
+	NoUsage string `flag:"nousage"`
+	BadType []byte `flag:"bytes,Bytes"`
+	BadInt  int    `flag:"int,default=x,Integer"`
+	BothDef string `flag:"both,default=a,Both" flag-default:"b"`
+	DupName int    `flag:"int,Another integer"`
+	Quotes  string `flag:"quotes,default='open,Bad quotes"`
+	Needs   string `flag:"needs,requires=nope,Needs another flag"`
+}
@@ -0,0 +1,10 @@
+// Package custom is a test input for flaxgen with a type registered at run
+// time.
+package custom
+
+// Color is registered with flax by the program.
+type Color struct{ R, G, B uint8 }
+
+type Paint struct {
+	Fill Color `flag:"fill,default=red,Fill color"`
+}
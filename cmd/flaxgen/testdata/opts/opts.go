@@ -0,0 +1,31 @@
+// Package opts is a test input for flaxgen.
+package opts
+
+import (
+	"strings"
+	"time"
+)
+
+// Options is a struct with flag tags.
+type Options struct {
+	Input  string        `flag:"input,Input file name (required)"`
+	DryRun bool          `flag:"dry-run,Dry run, do not make any changes"`
+	Count  int           `flag:"count,default=1,Number of iterations"`
+	Wait   time.Duration `flag:"wait,env=WAIT,Time to wait" flag-default:"5s"`
+	Tags   tagList       `flag:"tag,Tags to apply"`
+	Limit  time.Duration `flag:"limit,default=90s,requires=input,Time limit"`
+	Scale  float64       `flag:"scale,mutable,default=0.5,Scale factor"`
+
+	internal int
+	Ignored  string
+}
+
+// NoFlags is a struct without flag tags, which should be ignored.
+type NoFlags struct {
+	A, B int
+}
+
+type tagList []string
+
+func (t tagList) String() string      { return strings.Join(t, ",") }
+func (t *tagList) Set(s string) error { *t = append(*t, s); return nil }
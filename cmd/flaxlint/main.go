@@ -0,0 +1,154 @@
+// Copyright (C) 2023 Michael J. Fromberger. All Rights Reserved.
+
+// Program flaxlint checks the flag struct tags used by the flax package in Go
+// source code, reporting problems that [flax.Check] would otherwise only
+// report when a program runs.
+//
+// Usage:
+//
+//	flaxlint [-registered T,...] [package-dir ...]
+//
+// If no directories are given, the package in the current directory is
+// checked. A directory ending in "/..." includes all the package directories
+// beneath it, other than testdata, vendor, and hidden directories.
+//
+// Flaxlint reports tags with syntax errors (such as a missing usage string or
+// an unbalanced quotation), tags with an empty usage string, fields whose
+// types are not compatible with flags, default values that are not valid for
+// the type of their field, fields that set both a default option and a
+// flag-default tag, and flag names that are used more than once among the
+// structs of a package. Each problem is printed with its file position, in
+// order of position.
+//
+// A field whose type is not flag compatible may still be valid if the program
+// registers its type with flax at run time, as by [flax.RegisterType]. Such
+// types can be listed with -registered, naming types declared in the checked
+// package without qualification ("Color") and other types by import path
+// ("example.com/color.Color"). A field of any other named type that is not
+// flag compatible is reported as a warning.
+//
+// The exit status is 1 if any problems other than warnings were found, and 2
+// if the packages could not be read.
+package main
+
+import (
+	"cmp"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/creachadair/flax/internal/tagcheck"
+)
+
+var registered = flag.String("registered", "", "Comma-separated names of types registered at run time")
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s [options] [package-dir ...]\n\nOptions:\n", filepath.Base(os.Args[0]))
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	dirs, err := expandDirs(flag.Args())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "flaxlint: %v\n", err)
+		os.Exit(2)
+	}
+	cfg := new(tagcheck.Config)
+	if *registered != "" {
+		cfg.Registered = strings.Split(*registered, ",")
+	}
+	problems, err := lint(dirs, cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "flaxlint: %v\n", err)
+		os.Exit(2)
+	}
+	failed := false
+	for _, p := range problems {
+		fmt.Println(p)
+		failed = failed || !p.Warning
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
+
+// expandDirs returns the package directories named by args.
+func expandDirs(args []string) ([]string, error) {
+	if len(args) == 0 {
+		return []string{"."}, nil
+	}
+	var dirs []string
+	for _, arg := range args {
+		root, ok := strings.CutSuffix(arg, "/...")
+		if !ok {
+			dirs = append(dirs, arg)
+			continue
+		}
+		err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil || !d.IsDir() {
+				return err
+			}
+			name := d.Name()
+			if path != root && (name == "testdata" || name == "vendor" || strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_")) {
+				return filepath.SkipDir
+			}
+			if m, _ := filepath.Glob(filepath.Join(path, "*.go")); len(m) != 0 {
+				dirs = append(dirs, path)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return dirs, nil
+}
+
+// lint checks the packages in dirs using cfg and returns each problem found,
+// in order of position.
+func lint(dirs []string, cfg *tagcheck.Config) ([]*tagcheck.Diagnostic, error) {
+	var diags []*tagcheck.Diagnostic
+	for _, dir := range dirs {
+		pkg, ds, err := tagcheck.Load(dir, cfg)
+		if err != nil {
+			return nil, err
+		}
+		diags = append(diags, ds...)
+
+		// Check for flag names shared by multiple structs in the package.
+		// Duplicates within a single struct are reported by Load.
+		type use struct {
+			s *tagcheck.Struct
+			f *tagcheck.Field
+		}
+		seen := make(map[string]use)
+		for _, s := range pkg.Structs {
+			for _, f := range s.Fields {
+				prev, ok := seen[f.Spec.Name]
+				if !ok {
+					seen[f.Spec.Name] = use{s, f}
+				} else if prev.s != s {
+					diags = append(diags, &tagcheck.Diagnostic{
+						Pos:    f.Pos,
+						Struct: s.Name,
+						Field:  f.Spec.Field,
+						Message: fmt.Sprintf("flag name %q is also used by %s.%s at %s",
+							f.Spec.Name, prev.s.Name, prev.f.Spec.Field, prev.f.Pos),
+					})
+				}
+			}
+		}
+	}
+	slices.SortStableFunc(diags, func(a, b *tagcheck.Diagnostic) int {
+		return cmp.Or(
+			cmp.Compare(a.Pos.Filename, b.Pos.Filename),
+			cmp.Compare(a.Pos.Line, b.Pos.Line),
+			cmp.Compare(a.Pos.Column, b.Pos.Column),
+		)
+	})
+	return diags, nil
+}
@@ -0,0 +1,103 @@
+// Copyright (C) 2023 Michael J. Fromberger. All Rights Reserved.
+
+package main
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/creachadair/flax/internal/tagcheck"
+)
+
+func TestLint(t *testing.T) {
+	cfg := &tagcheck.Config{Registered: []string{"Color", "*Color"}}
+	diags, err := lint([]string{"testdata/pkg"}, cfg)
+	if err != nil {
+		t.Fatalf("Lint failed: %v", err)
+	}
+	got := problems(diags)
+	want := []string{
+		`pkg.go:17:2: Bad: field "NoUsage": invalid flag tag format "nousage"`,
+		`pkg.go:18:2: Bad: field "Quote": invalid default format`,
+		`pkg.go:19:2: Bad: field "Count": invalid default for "count"`,
+		`pkg.go:20:2: Bad: field "Wait": invalid default for "delay"`,
+		`pkg.go:21:2: Bad: field "Both": default tag and string are both set`,
+		`pkg.go:22:2: Bad: field "Kind": type complex128 is not flag compatible`,
+		`pkg.go:23:2: Bad: field "Again": flag name "name" is also used by Good.Name at testdata/pkg/pkg.go:12:2`,
+		`pkg.go:24:2: Bad: field "Blank": empty usage string`,
+		`pkg.go:25:2: Bad: field "Unsaid": empty usage string`,
+		`pkg.go:33:2: Std: field "Perm": invalid default for "perm": invalid octal file mode "0999"`,
+		`pkg.go:45:2: Custom: field "Level": warning: type pkg.Level is not flag compatible unless registered at run time`,
+	}
+	if len(got) != len(want) {
+		t.Errorf("Lint: got %d problems, want %d:\n%s", len(got), len(want), strings.Join(got, "\n"))
+	}
+	for i := 0; i < len(got) && i < len(want); i++ {
+		if !strings.Contains(got[i], want[i]) {
+			t.Errorf("Problem %d:\ngot:  %s\nwant: %s", i+1, got[i], want[i])
+		}
+	}
+	if last := diags[len(diags)-1]; !last.Warning {
+		t.Errorf("Problem %q is not a warning", last)
+	}
+}
+
+func TestLintUnregistered(t *testing.T) {
+	diags, err := lint([]string{"testdata/pkg"}, nil)
+	if err != nil {
+		t.Fatalf("Lint failed: %v", err)
+	}
+	var warned []string
+	for _, d := range diags {
+		if d.Struct == "Custom" {
+			if !d.Warning {
+				t.Errorf("Problem %q is not a warning", d)
+			}
+			warned = append(warned, d.Field)
+		}
+	}
+	if want := []string{"Fill", "Line", "Level"}; !slices.Equal(warned, want) {
+		t.Errorf("Warnings: got fields %q, want %q", warned, want)
+	}
+}
+
+// problems returns the text of each of diags.
+func problems(diags []*tagcheck.Diagnostic) []string {
+	out := make([]string, len(diags))
+	for i, d := range diags {
+		out[i] = d.Error()
+	}
+	return out
+}
+
+func TestLintClean(t *testing.T) {
+	got, err := lint([]string{"../flaxgen/testdata/opts"}, nil)
+	if err != nil {
+		t.Fatalf("Lint failed: %v", err)
+	}
+	if len(got) != 0 {
+		t.Errorf("Lint: got unexpected problems:\n%s", strings.Join(problems(got), "\n"))
+	}
+}
+
+func TestExpandDirs(t *testing.T) {
+	got, err := expandDirs([]string{"../..."})
+	if err != nil {
+		t.Fatalf("expandDirs failed: %v", err)
+	}
+	has := func(dir string) bool {
+		for _, d := range got {
+			if d == dir {
+				return true
+			}
+		}
+		return false
+	}
+	if !has("../flaxlint") || !has("../flaxgen") {
+		t.Errorf("expandDirs: got %q, missing command directories", got)
+	}
+	if has("testdata/pkg") || has("../flaxgen/testdata/opts") {
+		t.Errorf("expandDirs: got %q, includes testdata", got)
+	}
+}
@@ -0,0 +1,46 @@
+// Package pkg is a test input for flaxlint.
+package pkg
+
+import (
+	"net/url"
+	"os"
+	"regexp"
+	"time"
+)
+
+type Good struct {
+	Name string        `flag:"name,default='a, b',Name"`
+	Wait time.Duration `flag:"wait,default=$WAIT,Wait time"`
+}
+
+type Bad struct {
+	NoUsage string        `flag:"nousage"`
+	Quote   string        `flag:"quote,default='open,Quoted"`
+	Count   int           `flag:"count,default=many,Count"`
+	Wait    time.Duration `flag:"delay,Delay" flag-default:"soon"`
+	Both    string        `flag:"both,default=x,Both" flag-default:"y"`
+	Kind    complex128    `flag:"kind,Kind"`
+	Again   string        `flag:"name,Duplicate of Good.Name"`
+	Blank   string        `flag:"blank,"`
+	Unsaid  int           `flag:"unsaid,default=1,"`
+}
+
+type Std struct {
+	Home  *url.URL       `flag:"home,default=https://example.com,Home page"`
+	Match *regexp.Regexp `flag:"match,default='^a+$',Pattern"`
+	Zone  *time.Location `flag:"zone,default=UTC,Time zone"`
+	Mode  os.FileMode    `flag:"mode,default=0644,File mode"`
+	Perm  os.FileMode    `flag:"perm,default=0999,Bad file mode"`
+}
+
+// Color is a type registered with flax at run time.
+type Color struct{ R, G, B uint8 }
+
+// Level is a type not known to be registered.
+type Level struct{ N int }
+
+type Custom struct {
+	Fill  Color  `flag:"fill,default=red,Fill color"`
+	Line  *Color `flag:"line,Line color"`
+	Level Level  `flag:"level,Log level"`
+}
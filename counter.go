@@ -0,0 +1,62 @@
+// Copyright (C) 2023 Michael J. Fromberger. All Rights Reserved.
+
+package flax
+
+import (
+	"reflect"
+	"strconv"
+)
+
+// counterCodec returns a codec for an integer field tagged with the count
+// option. The caller must ensure the field type supports it.
+//
+// A counter is a boolean flag as far as the flag package is concerned, so
+// each occurrence without a value (which the flag package reports as "true")
+// increments the field. An explicit "false" resets it to zero, and any other
+// value must be an integer, which is stored as given.
+func counterCodec() *typeCodec {
+	return &typeCodec{
+		set: func(target any, s string) error {
+			v := reflect.ValueOf(target).Elem()
+			switch s {
+			case "true":
+				return incrCount(v)
+			case "false":
+				v.SetZero()
+				return nil
+			}
+			if v.CanInt() {
+				n, err := strconv.ParseInt(s, 10, v.Type().Bits())
+				if err != nil {
+					return err
+				}
+				v.SetInt(n)
+			} else {
+				n, err := strconv.ParseUint(s, 10, v.Type().Bits())
+				if err != nil {
+					return err
+				}
+				v.SetUint(n)
+			}
+			return nil
+		},
+		get:    func(target any) any { return reflect.ValueOf(target).Elem().Interface() },
+		format: func(target any) string { return formatValue(reflect.ValueOf(target).Elem().Interface()) },
+		isBool: true,
+	}
+}
+
+// incrCount increments the integer value v, reporting an error if the result
+// overflows.
+func incrCount(v reflect.Value) error {
+	if v.CanInt() {
+		if n := v.Int() + 1; n > v.Int() && !v.OverflowInt(n) {
+			v.SetInt(n)
+			return nil
+		}
+	} else if n := v.Uint() + 1; n > v.Uint() && !v.OverflowUint(n) {
+		v.SetUint(n)
+		return nil
+	}
+	return strconv.ErrRange
+}
@@ -0,0 +1,91 @@
+// Copyright (C) 2023 Michael J. Fromberger. All Rights Reserved.
+
+package flax_test
+
+import (
+	"flag"
+	"io"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/creachadair/flax"
+)
+
+func TestCounter(t *testing.T) {
+	type counters struct {
+		Verbose int    `flag:"v,count,Verbosity"`
+		Debug   uint64 `flag:"debug,count,default=1,Debug level"`
+		Small   int64  `flag:"small,count,Small"`
+	}
+	tests := []struct {
+		args []string
+		want counters
+		err  string
+	}{
+		{nil, counters{0, 1, 0}, ""},
+		{[]string{"-v"}, counters{1, 1, 0}, ""},
+		{[]string{"-v", "-v", "-v"}, counters{3, 1, 0}, ""},
+		{[]string{"-v=5", "-v"}, counters{6, 1, 0}, ""},
+		{[]string{"-v", "-v", "-v=false"}, counters{0, 1, 0}, ""},
+		{[]string{"-debug", "-debug", "-v=-2"}, counters{-2, 3, 0}, ""},
+		{[]string{"-v", "x", "-v"}, counters{1, 1, 0}, ""},
+		{[]string{"-small=9223372036854775807", "-small"}, counters{0, 1, math.MaxInt64}, "out of range"},
+		{[]string{"-debug=-1"}, counters{}, "invalid syntax"},
+		{[]string{"-v=lots"}, counters{}, "invalid syntax"},
+	}
+	for _, tc := range tests {
+		var flags counters
+		fi := flax.MustCheck(&flags)
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		fs.SetOutput(io.Discard)
+		fi.Bind(fs)
+		err := fs.Parse(tc.args)
+		if tc.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tc.err) {
+				t.Errorf("Parse %q: got %v, want error %q", tc.args, err, tc.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Parse %q: unexpected error: %v", tc.args, err)
+		} else if flags != tc.want {
+			t.Errorf("Parse %q: got %+v, want %+v", tc.args, flags, tc.want)
+		}
+	}
+}
+
+func TestCounterUsage(t *testing.T) {
+	var flags struct {
+		Verbose int `flag:"v,count,Verbosity"`
+	}
+	fi := flax.MustCheck(&flags)
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fi.Bind(fs)
+
+	var out strings.Builder
+	fs.SetOutput(&out)
+	fs.PrintDefaults()
+	if got, want := out.String(), "  -v\tVerbosity (repeatable)\n"; got != want {
+		t.Errorf("Usage: got %q, want %q", got, want)
+	}
+	if gv, ok := fi.Flag("v").Value().(interface{ IsBoolFlag() bool }); !ok || !gv.IsBoolFlag() {
+		t.Error("Value is not a boolean flag")
+	}
+
+	for _, tc := range []struct {
+		v    any
+		want string
+	}{
+		{&struct {
+			S string `flag:"s,count,Not an integer"`
+		}{}, "count option requires"},
+		{&struct {
+			N int64 `flag:"n,count,size,Both"`
+		}{}, "mutually exclusive"},
+	} {
+		if _, err := flax.Check(tc.v); err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("Check %T: got %v, want %q", tc.v, err, tc.want)
+		}
+	}
+}
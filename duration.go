@@ -0,0 +1,232 @@
+// Copyright (C) 2023 Michael J. Fromberger. All Rights Reserved.
+
+package flax
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// A Duration is a [time.Duration] whose flag syntax accepts days and weeks
+// as well as the units understood by [time.ParseDuration], and durations in
+// ISO 8601 format. See [ParseDuration] for details.
+//
+// A Duration is rendered in the same form, using the largest units that
+// represent it exactly, for example "1w2d", "1d12h", or "1m30s".
+type Duration time.Duration
+
+// String renders d in the format described by [FormatDuration].
+func (d Duration) String() string { return FormatDuration(time.Duration(d)) }
+
+// Set implements part of the [flag.Value] interface.
+func (d *Duration) Set(s string) error {
+	v, err := ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+	return nil
+}
+
+// Get implements part of the [flag.Getter] interface.
+func (d *Duration) Get() any { return *d }
+
+// durationUnits maps the unit names understood by ParseDuration to their
+// lengths in nanoseconds.
+var durationUnits = map[string]int64{
+	"ns": 1,
+	"us": 1e3, "µs": 1e3, "μs": 1e3,
+	"ms": 1e6,
+	"s":  1e9,
+	"m":  60e9,
+	"h":  3600e9,
+	"d":  24 * 3600e9,
+	"w":  7 * 24 * 3600e9,
+}
+
+var (
+	// durationPartRE matches one component of a duration: a decimal number and
+	// a unit.
+	durationPartRE = regexp.MustCompile(`^([0-9]*(?:\.[0-9]*)?)(ns|us|µs|μs|ms|s|m|h|d|w)`)
+
+	// isoDurationRE matches an ISO 8601 duration with weeks, days, hours,
+	// minutes, and seconds. Years and months are not supported, since their
+	// lengths vary.
+	isoDurationRE = regexp.MustCompile(`^P(?:([0-9.]+)W)?(?:([0-9.]+)D)?(?:T(?:([0-9.]+)H)?(?:([0-9.]+)M)?(?:([0-9.]+)S)?)?$`)
+)
+
+// ParseDuration parses a duration string. In addition to the format accepted
+// by [time.ParseDuration], the units "d" (24 hours) and "w" (7 days) are
+// understood, as in "7d" or "2w3d12h". An ISO 8601 duration such as "P1DT2H"
+// or "PT90S" is also accepted, with weeks, days, hours, minutes, and seconds;
+// years and months are not supported, since their lengths vary.
+func ParseDuration(s string) (time.Duration, error) {
+	orig := s
+	neg := false
+	if t, ok := strings.CutPrefix(s, "-"); ok {
+		neg, s = true, t
+	} else {
+		s = strings.TrimPrefix(s, "+")
+	}
+	var total *big.Rat
+	var err error
+	if strings.HasPrefix(s, "P") {
+		total, err = parseISODuration(s)
+	} else {
+		total, err = parseDurationParts(s)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("invalid duration %q: %w", orig, err)
+	}
+	ns := new(big.Int).Quo(total.Num(), total.Denom()) // truncate fractional ns
+	if neg {
+		ns.Neg(ns)
+	}
+	if !ns.IsInt64() {
+		return 0, fmt.Errorf("invalid duration %q: out of range", orig)
+	}
+	return time.Duration(ns.Int64()), nil
+}
+
+// parseDurationParts parses a sequence of number-unit pairs, and returns the
+// total length in nanoseconds.
+func parseDurationParts(s string) (*big.Rat, error) {
+	if s == "0" {
+		return new(big.Rat), nil
+	} else if s == "" {
+		return nil, errors.New("empty duration")
+	}
+	total := new(big.Rat)
+	for s != "" {
+		m := durationPartRE.FindStringSubmatch(s)
+		if m == nil || m[1] == "" || m[1] == "." {
+			return nil, errors.New("missing number or unit")
+		}
+		if err := addDuration(total, m[1], durationUnits[m[2]]); err != nil {
+			return nil, err
+		}
+		s = s[len(m[0]):]
+	}
+	return total, nil
+}
+
+// parseISODuration parses an ISO 8601 duration, and returns its length in
+// nanoseconds.
+func parseISODuration(s string) (*big.Rat, error) {
+	m := isoDurationRE.FindStringSubmatch(s)
+	if m == nil || s == "P" || strings.HasSuffix(s, "T") {
+		return nil, errors.New("invalid ISO 8601 duration")
+	}
+	total := new(big.Rat)
+	for i, unit := range []string{"w", "d", "h", "m", "s"} {
+		if m[i+1] == "" {
+			continue
+		}
+		if err := addDuration(total, m[i+1], durationUnits[unit]); err != nil {
+			return nil, err
+		}
+	}
+	return total, nil
+}
+
+// addDuration adds num units of the given length in nanoseconds to total.
+func addDuration(total *big.Rat, num string, unit int64) error {
+	r, ok := new(big.Rat).SetString(num)
+	if !ok {
+		return fmt.Errorf("invalid number %q", num)
+	}
+	total.Add(total, r.Mul(r, big.NewRat(unit, 1)))
+	return nil
+}
+
+// FormatDuration renders d in the format accepted by [ParseDuration], using
+// weeks and days for the whole-day part of the duration and the format of
+// [time.Duration.String] for the rest, omitting zero minutes and seconds.
+func FormatDuration(d time.Duration) string {
+	if d == 0 {
+		return "0s"
+	}
+	var sb strings.Builder
+	if d < 0 {
+		sb.WriteString("-")
+	}
+	n := uint64(d)
+	if d < 0 {
+		n = -n
+	}
+	const day = 24 * uint64(time.Hour)
+	if w := n / (7 * day); w > 0 {
+		fmt.Fprintf(&sb, "%dw", w)
+		n %= 7 * day
+	}
+	if dd := n / day; dd > 0 {
+		fmt.Fprintf(&sb, "%dd", dd)
+		n %= day
+	}
+	if n != 0 {
+		rest := time.Duration(n).String()
+		if t, ok := strings.CutSuffix(rest, "m0s"); ok {
+			rest = t + "m"
+		}
+		if t, ok := strings.CutSuffix(rest, "h0m"); ok {
+			rest = t + "h"
+		}
+		sb.WriteString(rest)
+	}
+	return sb.String()
+}
+
+// durationCodec returns a codec for a field of type time.Duration or
+// Duration, as selected by ext, applying the days, min, and max options. It
+// returns nil for a time.Duration field that uses none of these options.
+func durationCodec(opts map[string]string, ext bool) (*typeCodec, error) {
+	_, days := opts["days"]
+	minText, hasMin := opts["min"]
+	maxText, hasMax := opts["max"]
+	if !ext && !days && !hasMin && !hasMax {
+		return nil, nil
+	}
+	parse, format := time.ParseDuration, time.Duration.String
+	if ext || days {
+		parse, format = ParseDuration, FormatDuration
+	}
+
+	lo, hi := time.Duration(math.MinInt64), time.Duration(math.MaxInt64)
+	var err error
+	if hasMin {
+		if lo, err = ParseDuration(minText); err != nil {
+			return nil, fmt.Errorf("invalid min: %w", err)
+		}
+	}
+	if hasMax {
+		if hi, err = ParseDuration(maxText); err != nil {
+			return nil, fmt.Errorf("invalid max: %w", err)
+		}
+	}
+	if lo > hi {
+		return nil, fmt.Errorf("min %s is greater than max %s", FormatDuration(lo), FormatDuration(hi))
+	}
+	check := func(s string) (time.Duration, error) {
+		d, err := parse(s)
+		if err != nil {
+			return 0, err
+		} else if d < lo {
+			return 0, fmt.Errorf("duration %s is less than the minimum %s", format(d), format(lo))
+		} else if d > hi {
+			return 0, fmt.Errorf("duration %s is greater than the maximum %s", format(d), format(hi))
+		}
+		return d, nil
+	}
+	if ext {
+		return newCodec(func(s string) (Duration, error) {
+			d, err := check(s)
+			return Duration(d), err
+		}, Duration.String), nil
+	}
+	return newCodec(check, format), nil
+}
@@ -0,0 +1,188 @@
+// Copyright (C) 2023 Michael J. Fromberger. All Rights Reserved.
+
+package flax_test
+
+import (
+	"flag"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/creachadair/flax"
+)
+
+const day = 24 * time.Hour
+
+func TestParseDuration(t *testing.T) {
+	tests := []struct {
+		input string
+		want  time.Duration
+		err   string
+	}{
+		{"0", 0, ""},
+		{"1s", time.Second, ""},
+		{"1h30m", 90 * time.Minute, ""},
+		{"7d", 7 * day, ""},
+		{"2w", 14 * day, ""},
+		{"1w2d3h", 9*day + 3*time.Hour, ""},
+		{"1.5d", 36 * time.Hour, ""},
+		{"-3d", -3 * day, ""},
+		{"+12h", 12 * time.Hour, ""},
+		{"250ms", 250 * time.Millisecond, ""},
+		{"1.5ns", 1, ""},
+		{"P1DT2H", day + 2*time.Hour, ""},
+		{"PT90S", 90 * time.Second, ""},
+		{"P2W", 14 * day, ""},
+		{"PT1.5M", 90 * time.Second, ""},
+		{"-PT1H", -time.Hour, ""},
+		{"15250w", 15250 * 7 * day, ""},
+
+		{"", 0, "empty duration"},
+		{"3", 0, "missing number or unit"},
+		{"d", 0, "missing number or unit"},
+		{"1x", 0, "missing number or unit"},
+		{"1d ", 0, "missing number or unit"},
+		{"P", 0, "invalid ISO 8601"},
+		{"P1Y", 0, "invalid ISO 8601"},
+		{"P1M", 0, "invalid ISO 8601"},
+		{"P1DT", 0, "invalid ISO 8601"},
+		{"PT1.2.3S", 0, "invalid number"},
+		{"15251w", 0, "out of range"},
+	}
+	for _, tc := range tests {
+		got, err := flax.ParseDuration(tc.input)
+		if tc.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tc.err) {
+				t.Errorf("ParseDuration(%q): got (%v, %v), want error %q", tc.input, got, err, tc.err)
+			}
+		} else if err != nil {
+			t.Errorf("ParseDuration(%q): unexpected error: %v", tc.input, err)
+		} else if got != tc.want {
+			t.Errorf("ParseDuration(%q): got %v, want %v", tc.input, got, tc.want)
+		}
+	}
+}
+
+func TestFormatDuration(t *testing.T) {
+	tests := []struct {
+		input time.Duration
+		want  string
+	}{
+		{0, "0s"},
+		{time.Second, "1s"},
+		{90 * time.Second, "1m30s"},
+		{3 * time.Hour, "3h"},
+		{90 * time.Minute, "1h30m"},
+		{day, "1d"},
+		{36 * time.Hour, "1d12h"},
+		{9 * day, "1w2d"},
+		{14*day + time.Minute, "2w1m"},
+		{-3 * day, "-3d"},
+		{1500 * time.Millisecond, "1.5s"},
+		{250 * time.Millisecond, "250ms"},
+	}
+	for _, tc := range tests {
+		got := flax.FormatDuration(tc.input)
+		if got != tc.want {
+			t.Errorf("FormatDuration(%v): got %q, want %q", tc.input, got, tc.want)
+		}
+		if back, err := flax.ParseDuration(got); err != nil || back != tc.input {
+			t.Errorf("ParseDuration(%q): got (%v, %v), want %v", got, back, err, tc.input)
+		}
+	}
+}
+
+func TestDurationFlags(t *testing.T) {
+	var flags struct {
+		Retain  flax.Duration `flag:"retain,default=2w,Retention"`
+		Expire  time.Duration `flag:"expire,days,default=P1D,Expiry"`
+		Timeout time.Duration `flag:"timeout,min=1s,max=1m,default=10s,Timeout"`
+		Window  flax.Duration `flag:"window,min=1d,max=4w,default=1w,Window"`
+		Plain   time.Duration `flag:"plain,Plain duration"`
+	}
+	fi, err := flax.Check(&flags)
+	if err != nil {
+		t.Fatalf("Check failed: %v", err)
+	}
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	fi.Bind(fs)
+
+	if flags.Retain != flax.Duration(14*day) || flags.Expire != day || flags.Timeout != 10*time.Second {
+		t.Errorf("Defaults: got %+v", flags)
+	}
+	if got := fi.Flag("expire").Default(); got != "1d" {
+		t.Errorf("Expire default: got %q, want 1d", got)
+	}
+	if err := fs.Parse([]string{
+		"-retain", "30d", "-expire", "36h", "-timeout", "1m", "-window", "PT24H", "-plain", "2h",
+	}); err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	want := map[string]string{
+		"retain": "4w2d", "expire": "1d12h", "timeout": "1m0s", "window": "1d", "plain": "2h0m0s",
+	}
+	for name, w := range want {
+		if got := fi.Flag(name).Value().String(); got != w {
+			t.Errorf("Value %q: got %q, want %q", name, got, w)
+		}
+	}
+
+	for _, tc := range []struct {
+		arg, want string
+	}{
+		{"-timeout=500ms", "less than the minimum 1s"},
+		{"-timeout=2m", "greater than the maximum 1m0s"},
+		{"-timeout=1d", "unknown unit"},
+		{"-window=5w", "greater than the maximum 4w"},
+		{"-plain=1d", "parse error"},
+		{"-retain=forever", "invalid duration"},
+	} {
+		if err := fs.Parse([]string{tc.arg}); err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("Parse %q: got %v, want %q", tc.arg, err, tc.want)
+		}
+	}
+
+	var out strings.Builder
+	fs.SetOutput(&out)
+	fs.PrintDefaults()
+	for _, want := range []string{"(default 2w)", "(default 1d)", "(default 10s)"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("Usage: missing %q:\n%s", want, out.String())
+		}
+	}
+}
+
+func TestDurationOptionErrors(t *testing.T) {
+	for _, tc := range []struct {
+		v    any
+		want string
+	}{
+		{&struct {
+			D time.Duration `flag:"d,min=1h,max=1m,Backwards"`
+		}{}, "min 1h is greater than max 1m"},
+		{&struct {
+			D time.Duration `flag:"d,min=soon,Bad min"`
+		}{}, "invalid min"},
+		{&struct {
+			D time.Duration `flag:"d,min=1m,default=1s,Bad default"`
+		}{}, "invalid default"},
+		{&struct {
+			D time.Duration `flag:"retain,days,min=1d,max=52w,Retention"`
+		}{}, "duration 0s is less than the minimum 1d"},
+		{&struct {
+			D flax.Duration `flag:"d,max=1h,Too long"`
+		}{D: flax.Duration(2 * time.Hour)}, "duration 2h is greater than the maximum 1h"},
+		{&struct {
+			D flax.Duration `flag:"d,days,Redundant"`
+		}{}, "days option requires a field of type time.Duration"},
+		{&struct {
+			N int `flag:"n,max=5m,Not a duration"`
+		}{}, "max option requires a field of type time.Duration or flax.Duration"},
+	} {
+		if _, err := flax.Check(tc.v); err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("Check %T: got %v, want %q", tc.v, err, tc.want)
+		}
+	}
+}
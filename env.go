@@ -0,0 +1,149 @@
+// Copyright (C) 2023 Michael J. Fromberger. All Rights Reserved.
+
+package flax
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// BindEnv binds each field of f to an environment variable whose name is
+// derived from prefix and the flag name. The name is formed by converting the
+// flag name to upper case, replacing any characters other than letters and
+// digits with underscores, and joining the result to prefix with an
+// underscore. For example, with prefix "MYTOOL" the flag "dry-run" is bound to
+// MYTOOL_DRY_RUN. If prefix == "", the converted flag name is used alone.
+//
+// A field whose tag has an explicit env option keeps that name.
+// Environment bindings are applied when flags are parsed by [Fields.Parse].
+// BindEnv should be called before [Fields.Bind], so that the usage text for
+// each flag mentions its environment variable.
+func (f Fields) BindEnv(prefix string) {
+	for _, fi := range f {
+		if _, ok := fi.opts["env"]; !ok {
+			fi.envKey = envName(prefix, fi.Name)
+		}
+	}
+}
+
+// parseEnv sets the flags for environment-bound fields of f not already set
+// in fs from the environment.
+func (f Fields) parseEnv(fs *flag.FlagSet) error {
+	set := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) { set[f.Name] = true })
+	for _, fi := range f {
+		if fi.envKey == "" || set[fi.Name] || fs.Lookup(fi.Name) == nil {
+			continue
+		} else if fi.isNegatable() && set[negatedPrefix+fi.Name] {
+			continue
+		}
+		val := os.Getenv(fi.envKey)
+		if val == "" {
+			continue
+		}
+		if err := fs.Set(fi.Name, val); err != nil {
+			return fmt.Errorf("invalid value %q for environment variable %s (flag -%s): %w",
+				val, fi.envKey, fi.Name, err)
+		}
+		fi.fromEnv = true
+	}
+	return nil
+}
+
+// EnvVar reports the name of the environment variable to which fi is bound
+// at parse time. It returns "" if the field is not bound to the environment.
+func (fi *Field) EnvVar() string { return fi.envKey }
+
+// WriteEnvTemplate writes a template in .env format to w listing each
+// environment variable known to f, either as the source of a default value or
+// as a parse-time binding. Each variable is preceded by a comment giving the
+// usage text of its flag, and is assigned the default value of the flag.
+//
+// The template does not copy values from the environment: a variable is left
+// empty if its field has the secret option, or takes its default from an
+// environment variable.
+func (f Fields) WriteEnvTemplate(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	f.visitEnv(func(fi *Field, key string) {
+		val := fi.dtext
+		if _, secret := fi.opts["secret"]; secret || fi.env != "" {
+			val = ""
+		}
+		fmt.Fprintf(bw, "# %s (flag -%s)\n", fi.Usage, fi.Name)
+		fmt.Fprintf(bw, "%s=%s\n\n", key, envQuote(val))
+	})
+	return bw.Flush()
+}
+
+// WriteExports writes a shell script to w that exports the current value of
+// each field of f to each environment variable known to f, as for
+// [Fields.WriteEnvTemplate]. Values are single-quoted for the shell, so the
+// output can be sourced to reproduce the current configuration. The values of
+// fields with the secret option are not exported; their variables are listed
+// in comments with the value [Redacted].
+func (f Fields) WriteExports(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	f.visitEnv(func(fi *Field, key string) {
+		if _, secret := fi.opts["secret"]; secret {
+			fmt.Fprintf(bw, "# export %s=%s\n", key, shellQuote(Redacted))
+			return
+		}
+		fmt.Fprintf(bw, "export %s=%s\n", key, shellQuote(fi.current()))
+	})
+	return bw.Flush()
+}
+
+// visitEnv calls visit for each distinct environment variable known to f, in
+// order of occurrence.
+func (f Fields) visitEnv(visit func(*Field, string)) {
+	seen := make(map[string]bool)
+	for _, fi := range f {
+		for _, key := range []string{fi.env, fi.envKey} {
+			if key != "" && !seen[key] {
+				seen[key] = true
+				visit(fi, key)
+			}
+		}
+	}
+}
+
+// shellQuote quotes s for the shell, enclosing it in single quotes.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// envQuote quotes s for a .env file, if necessary. A quoted value is enclosed
+// in double quotes, with backslash escapes for characters that .env readers
+// would otherwise interpret.
+func envQuote(s string) string {
+	if !strings.ContainsAny(s, " \t\n\"'\\$#`") {
+		return s
+	}
+	return `"` + envEscaper.Replace(s) + `"`
+}
+
+// envEscaper escapes the special characters of a double-quoted .env value.
+var envEscaper = strings.NewReplacer(
+	`\`, `\\`, `"`, `\"`, "$", `\$`, "`", "\\`", "\n", `\n`,
+)
+
+// envName returns the environment variable name for a flag with the given
+// name and prefix.
+func envName(prefix, name string) string {
+	key := strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' {
+			return r - 'a' + 'A'
+		} else if r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' {
+			return r
+		}
+		return '_'
+	}, name)
+	if prefix == "" {
+		return key
+	}
+	return prefix + "_" + key
+}
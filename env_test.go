@@ -0,0 +1,188 @@
+// Copyright (C) 2023 Michael J. Fromberger. All Rights Reserved.
+
+package flax_test
+
+import (
+	"bytes"
+	"flag"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/creachadair/flax"
+)
+
+func TestBindEnv(t *testing.T) {
+	t.Setenv("MYTOOL_DRY_RUN", "true")
+	t.Setenv("MYTOOL_COUNT", "5")
+	t.Setenv("MYTOOL_NAME", "ignored")
+	t.Setenv("CUSTOM_WAIT", "3s")
+	t.Setenv("MYTOOL_LABEL", "")
+
+	var flags struct {
+		DryRun bool          `flag:"dry-run,Dry run"`
+		Count  int           `flag:"count,default=1,Count"`
+		Name   string        `flag:"name,Name"`
+		Wait   time.Duration `flag:"wait,env=CUSTOM_WAIT,Wait time"`
+		Label  string        `flag:"label,default=none,Label"`
+	}
+	fi, err := flax.Check(&flags)
+	if err != nil {
+		t.Fatalf("Check failed: %v", err)
+	}
+	fi.BindEnv("MYTOOL")
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fi.Bind(fs)
+	if err := fi.Parse(fs, []string{"-name", "cmdline"}); err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	if !flags.DryRun {
+		t.Error("DryRun: got false, want true")
+	}
+	if flags.Count != 5 {
+		t.Errorf("Count: got %d, want 5", flags.Count)
+	}
+	if flags.Name != "cmdline" {
+		t.Errorf("Name: got %q, want cmdline", flags.Name)
+	}
+	if flags.Wait != 3*time.Second {
+		t.Errorf("Wait: got %v, want 3s", flags.Wait)
+	}
+	if flags.Label != "none" {
+		t.Errorf("Label: got %q, want none", flags.Label)
+	}
+
+	for name, want := range map[string]string{
+		"dry-run": "MYTOOL_DRY_RUN",
+		"count":   "MYTOOL_COUNT",
+		"wait":    "CUSTOM_WAIT",
+	} {
+		if got := fi.Flag(name).EnvVar(); got != want {
+			t.Errorf("Flag %q env var: got %q, want %q", name, got, want)
+		}
+	}
+
+	var help bytes.Buffer
+	fs.SetOutput(&help)
+	fs.PrintDefaults()
+	if !strings.Contains(help.String(), "Wait time [env: CUSTOM_WAIT]") {
+		t.Errorf("Usage does not mention environment:\n%s", help.String())
+	}
+}
+
+func TestEnvTagOnly(t *testing.T) {
+	t.Setenv("WAIT", "2s")
+	t.Setenv("OTHER", "ignored")
+
+	var flags struct {
+		Wait  time.Duration `flag:"wait,env=WAIT,Wait time"`
+		Other string        `flag:"other,Not bound"`
+	}
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fi := flax.MustCheck(&flags)
+	fi.Bind(fs)
+	if err := fi.Parse(fs, nil); err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if flags.Wait != 2*time.Second {
+		t.Errorf("Wait: got %v, want 2s", flags.Wait)
+	}
+	if flags.Other != "" {
+		t.Errorf("Other: got %q, want empty", flags.Other)
+	}
+}
+
+func TestBindEnvError(t *testing.T) {
+	t.Setenv("APP_LIMIT", "lots")
+
+	var flags struct {
+		Limit int `flag:"limit,Limit"`
+	}
+	fi := flax.MustCheck(&flags)
+	fi.BindEnv("APP")
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fi.Bind(fs)
+
+	err := fi.Parse(fs, nil)
+	if err == nil {
+		t.Fatalf("Parse: got %+v, want error", flags)
+	} else if !strings.Contains(err.Error(), "APP_LIMIT") {
+		t.Errorf("Parse error %q does not mention APP_LIMIT", err)
+	}
+}
+
+func TestWriteEnv(t *testing.T) {
+	var flags struct {
+		Host  string        `flag:"host,default=localhost,Server host"`
+		Wait  time.Duration `flag:"wait,default=$WAIT_TIME,Wait time"`
+		Label string        `flag:"label,default='it''s here',Label"`
+		Note  string        `flag:"note,default='say \"hi\" to $USER',Note"`
+		Debug bool          `flag:"debug,Debug mode"`
+		Token string        `flag:"token,secret,default=$TEST_API_TOKEN,API token"`
+	}
+	t.Setenv("WAIT_TIME", "5s")
+	t.Setenv("TEST_API_TOKEN", "hunter2")
+	fi := flax.MustCheck(&flags)
+	fi.BindEnv("APP")
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fi.Bind(fs)
+	if err := fs.Parse([]string{"-host", "example.com", "-debug"}); err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	t.Run("Template", func(t *testing.T) {
+		var buf strings.Builder
+		if err := fi.WriteEnvTemplate(&buf); err != nil {
+			t.Fatalf("WriteEnvTemplate: unexpected error: %v", err)
+		}
+		const want = `# Server host (flag -host)
+APP_HOST=localhost
+
+# Wait time (flag -wait)
+WAIT_TIME=
+
+# Wait time (flag -wait)
+APP_WAIT=
+
+# Label (flag -label)
+APP_LABEL="it's here"
+
+# Note (flag -note)
+APP_NOTE="say \"hi\" to \$USER"
+
+# Debug mode (flag -debug)
+APP_DEBUG=false
+
+# API token (flag -token)
+TEST_API_TOKEN=
+
+# API token (flag -token)
+APP_TOKEN=
+
+`
+		if got := buf.String(); got != want {
+			t.Errorf("Template:\ngot:\n%s\nwant:\n%s", got, want)
+		}
+	})
+
+	t.Run("Exports", func(t *testing.T) {
+		var buf strings.Builder
+		if err := fi.WriteExports(&buf); err != nil {
+			t.Fatalf("WriteExports: unexpected error: %v", err)
+		}
+		const want = `export APP_HOST='example.com'
+export WAIT_TIME='5s'
+export APP_WAIT='5s'
+export APP_LABEL='it'\''s here'
+export APP_NOTE='say "hi" to $USER'
+export APP_DEBUG='true'
+# export TEST_API_TOKEN='[redacted]'
+# export APP_TOKEN='[redacted]'
+`
+		if got := buf.String(); got != want {
+			t.Errorf("Exports:\ngot:\n%s\nwant:\n%s", got, want)
+		}
+	})
+}
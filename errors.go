@@ -0,0 +1,96 @@
+// Copyright (C) 2023 Michael J. Fromberger. All Rights Reserved.
+
+package flax
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// An ErrorKind classifies the problem reported by a [FieldError].
+type ErrorKind int
+
+// Kinds of field errors.
+const (
+	KindTag     ErrorKind = iota + 1 // the flag tag is malformed
+	KindType                         // the field type is not flag compatible
+	KindDefault                      // the default value is not valid
+	KindEnv                          // the default from an environment variable is not valid
+	KindOption                       // a tag option is not valid for the field
+)
+
+var kindNames = [...]string{
+	KindTag:     "tag syntax",
+	KindType:    "unsupported type",
+	KindDefault: "bad default",
+	KindEnv:     "bad environment default",
+	KindOption:  "bad option",
+}
+
+func (k ErrorKind) String() string {
+	if k > 0 && int(k) < len(kindNames) {
+		return kindNames[k]
+	}
+	return fmt.Sprintf("ErrorKind(%d)", int(k))
+}
+
+// A FieldError describes a problem with a single struct field found by
+// [Check].
+type FieldError struct {
+	Field string    // the name of the struct field
+	Flag  string    // the flag name, if known
+	Tag   string    // the text of the struct tag
+	Kind  ErrorKind // the kind of problem
+	Err   error     // the underlying error
+}
+
+// Error implements the error interface.
+func (e *FieldError) Error() string { return fmt.Sprintf("field %q: %v", e.Field, e.Err) }
+
+// Unwrap returns the underlying error of e.
+func (e *FieldError) Unwrap() error { return e.Err }
+
+// FieldErrors is the error reported by [Check] when one or more fields are
+// not valid. It describes every problem found. Use [errors.As] to recover a
+// FieldErrors value or any of its *FieldError elements from an error.
+type FieldErrors []*FieldError
+
+// Error implements the error interface. Each field error is reported on a
+// separate line.
+func (e FieldErrors) Error() string {
+	msgs := make([]string, len(e))
+	for i, fe := range e {
+		msgs[i] = fe.Error()
+	}
+	return strings.Join(msgs, "\n")
+}
+
+// Unwrap returns the elements of e.
+func (e FieldErrors) Unwrap() []error {
+	errs := make([]error, len(e))
+	for i, fe := range e {
+		errs[i] = fe
+	}
+	return errs
+}
+
+// A kindError is an error classified by kind, reported by newField.
+type kindError struct {
+	kind ErrorKind
+	err  error
+}
+
+func (e kindError) Error() string { return e.err.Error() }
+
+func (e kindError) Unwrap() error { return e.err }
+
+// errorKind reports the kind of err, defaulting to KindOption for errors not
+// otherwise classified.
+func errorKind(err error) ErrorKind {
+	var ke kindError
+	if errors.As(err, &ke) {
+		return ke.kind
+	}
+	return KindOption
+}
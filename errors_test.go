@@ -0,0 +1,82 @@
+// Copyright (C) 2023 Michael J. Fromberger. All Rights Reserved.
+
+package flax_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/creachadair/flax"
+)
+
+func TestFieldErrors(t *testing.T) {
+	t.Setenv("TEST_ERRORS_COUNT", "lots")
+
+	var flags struct {
+		OK      string   `flag:"ok,Fine"`
+		BadTag  string   `flag:"nocomma"`
+		BadType []string `flag:"list,A list"`
+		BadDef  int      `flag:"num,default=x,A number"`
+		BadEnv  int      `flag:"count,default=$TEST_ERRORS_COUNT,A count"`
+		BadOpt  string   `flag:"size,size,Not an integer"`
+		BadReq  bool     `flag:"req,requires=nonesuch,Requires a missing flag"`
+	}
+	_, err := flax.Check(&flags)
+	if err == nil {
+		t.Fatal("Check: got nil, want error")
+	}
+
+	var fes flax.FieldErrors
+	if !errors.As(err, &fes) {
+		t.Fatalf("Check: got %T, want FieldErrors", err)
+	}
+	type result struct {
+		field, flag string
+		kind        flax.ErrorKind
+	}
+	want := []result{
+		{"BadTag", "", flax.KindTag},
+		{"BadType", "list", flax.KindType},
+		{"BadDef", "num", flax.KindDefault},
+		{"BadEnv", "count", flax.KindEnv},
+		{"BadOpt", "size", flax.KindOption},
+		{"BadReq", "req", flax.KindOption},
+	}
+	if len(fes) != len(want) {
+		t.Fatalf("Check: got %d errors, want %d:\n%v", len(fes), len(want), err)
+	}
+	for i, fe := range fes {
+		if got := (result{fe.Field, fe.Flag, fe.Kind}); got != want[i] {
+			t.Errorf("Error %d: got %+v, want %+v", i, got, want[i])
+		}
+		if !strings.HasPrefix(fe.Tag, `flag:"`) {
+			t.Errorf("Error %d: got tag %q, want the field tag", i, fe.Tag)
+		}
+		if !strings.Contains(err.Error(), fe.Error()) {
+			t.Errorf("Error %d: message %q missing from %q", i, fe.Error(), err.Error())
+		}
+	}
+
+	var fe *flax.FieldError
+	if !errors.As(err, &fe) || fe.Field != "BadTag" {
+		t.Errorf("As *FieldError: got %+v, want the first error", fe)
+	}
+	if got := flax.KindEnv.String(); got != "bad environment default" {
+		t.Errorf("KindEnv.String: got %q", got)
+	}
+	if got := flax.ErrorKind(99).String(); got != "ErrorKind(99)" {
+		t.Errorf("ErrorKind(99).String: got %q", got)
+	}
+}
+
+func TestFieldErrorsOnly(t *testing.T) {
+	var flags struct {
+		A string `flag:"a,default=x,default=y,Duplicate"`
+	}
+	_, err := flax.Check(&flags)
+	var fes flax.FieldErrors
+	if !errors.As(err, &fes) || len(fes) != 1 || fes[0].Kind != flax.KindTag {
+		t.Errorf("Check: got %v, want one tag error", err)
+	}
+}
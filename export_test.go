@@ -0,0 +1,16 @@
+// Copyright (C) 2023 Michael J. Fromberger. All Rights Reserved.
+
+package flax
+
+import (
+	"maps"
+	"reflect"
+	"slices"
+)
+
+// ClearTypeCache discards the cached analysis of struct types, for
+// benchmarking.
+func ClearTypeCache() { typeCache.Clear() }
+
+// StdTypes returns the standard library types with built-in support.
+func StdTypes() []reflect.Type { return slices.Collect(maps.Keys(stdTypes)) }
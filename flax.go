@@ -34,6 +34,25 @@
 //
 //	flax.MustBindAll(flagSet, &flags1, &flags2)
 //
+// For programs whose flags are described by a single struct type, the [Parse]
+// function combines constructing, binding, and parsing in one call:
+//
+//	opts, args, err := flax.Parse[Options](os.Args[1:])
+//
+// # Environment
+//
+// A field may be bound to an environment variable with the env tag option, or
+// all the fields of a [Fields] value may be bound to variables derived from
+// their flag names with [Fields.BindEnv]. Bound variables are consulted by
+// [Fields.Parse] for flags not set on the command line:
+//
+//	fs, err := flax.Check(&flags)
+//	...
+//	fs.BindEnv("MYTOOL") // e.g., "dry-run" is bound to MYTOOL_DRY_RUN
+//	fs.Bind(flag.CommandLine)
+//	...
+//	if err := fs.Parse(flag.CommandLine, os.Args[1:]); err != nil { ... }
+//
 // # Supported Types
 //
 // This package can bind a field of any of the default types supported by the
@@ -47,15 +66,22 @@
 //
 // If a field implements both [flag.Value] and the text marshaling interfaces,
 // the flag value implementation is used.
+//
+// Several common standard library types, such as [time.Time], [net.IP], and
+// [*url.URL], are also supported; see [Check] for the full list. Other types
+// can be supported by registering parse and format functions for them with
+// [RegisterType], or in a [Registry] scoped to a library.
 package flax
 
 import (
 	"errors"
 	"flag"
 	"fmt"
+	"maps"
 	"os"
 	"reflect"
 	"regexp"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
@@ -65,18 +91,34 @@ import (
 // of v must be a pointer to a value of struct type.  This function is intended
 // for use in program initialization; callers who need to check errors should
 // call [Check] and [Fields.Bind].
+//
+// If fs has the default usage function of a flag set returned by
+// [flag.NewFlagSet], MustBind replaces it with one that prints the flags of fs
+// as [Fields.PrintDefaults] does, so that help output lists each negatable
+// flag once and summarizes the constraints among the fields. A usage function
+// set by the caller, including that of [flag.CommandLine], is not changed.
 func MustBind(fs *flag.FlagSet, v any) {
 	fi, err := Check(v)
 	if err != nil {
 		panic("check flags: " + err.Error())
 	}
 	fi.Bind(fs)
+	if hasDefaultUsage(fs) {
+		fi.setUsage(fs)
+	}
 }
 
-// MustBindAll is shorthand for calling MustBind(fs, v) for each v in vs.
+// MustBindAll is shorthand for calling MustBind(fs, v) for each v in vs,
+// except that the usage message set for fs describes the fields of all of vs.
 func MustBindAll(fs *flag.FlagSet, vs ...any) {
+	var all Fields
 	for _, v := range vs {
-		MustBind(fs, v)
+		fi := MustCheck(v)
+		fi.Bind(fs)
+		all = append(all, fi...)
+	}
+	if hasDefaultUsage(fs) {
+		all.setUsage(fs)
 	}
 }
 
@@ -95,8 +137,10 @@ func MustCheck(v any) Fields {
 // type must be a pointer to a value of struct type.
 //
 // Check reports an error if v has the wrong type, or if it does not define any
-// flaggable fields.  An exported field of v is flaggable if it is of a
-// compatible type and has a struct tag with the following form:
+// flaggable fields. If any flag tags or fields are not valid, Check reports
+// all the problems it finds as a [FieldErrors] value. An exported field of v
+// is flaggable if it is of a compatible type and has a struct tag with the
+// following form:
 //
 //	flag:"name[,default=V],Usage string"
 //
@@ -120,36 +164,141 @@ func MustCheck(v any) Fields {
 //
 // The two forms are mutually exclusive, even if the values are identical.
 //
+// The env option binds the field to an environment variable that is read when
+// flags are parsed by [Fields.Parse]:
+//
+//	flag:"name,env=VAR,Usage string"
+//
+// See also [Fields.BindEnv].
+//
+// The reloadable option marks a field whose value may be changed when a
+// configuration is reloaded by a [Live] value:
+//
+//	flag:"name,reloadable,Usage string"
+//
+// Similarly, the mutable option marks a field whose value may be changed at
+// runtime by the handler in the [github.com/creachadair/flax/flaxhttp]
+// package, and the secret option marks a field whose value should not be
+// displayed or logged.
+//
 // Compatible types include bool, float64, int, int64, string, [time.Duration],
 // uint, and uint64, as well as any type implementing the [flag.Value] interface
 // or the [encoding.TextMarshaler] and [encoding.TextUnmarshaler] interfaces.
 // If a field implements both [flag.Value] and the text marshaling interfaces,
 // the flag value implementation is used.
+//
+// In addition, the following standard library types are compatible:
+// [time.Time], [*time.Location], [net.IP], [netip.Addr], [netip.AddrPort],
+// [*url.URL], [*regexp.Regexp], [os.FileMode] (written in octal), and
+// [slog.Level]. Except for the last two, an empty value sets these to their
+// zero value. A time.Time field is parsed using the RFC 3339 layout unless the
+// layout option gives another, either as a layout string or as the name of
+// one of the layout constants of the time package:
+//
+//	flag:"since,layout=DateOnly,Usage string"
+//
+// The size option allows an int64 or uint64 field to be written with a unit
+// suffix, in the format described by [Size]:
+//
+//	flag:"limit,size,default=64KiB,Usage string"
+//
+// The count option makes an integer field a counter: each occurrence of the
+// flag without a value, as in "-v -v -v", increments the field, while a value
+// such as "-v=5" sets it explicitly and "-v=false" resets it to zero:
+//
+//	flag:"v,count,Verbosity level"
+//
+// The negatable option on a bool field also binds a flag named "no-" plus
+// the flag name, which sets the field to false, for example "-no-cache". Use
+// [Fields.PrintDefaults] to list both forms together in usage text:
+//
+//	flag:"cache,negatable,default=true,Usage string"
+//
+// The xor and oneof options place a field in a named group of flags: at most
+// one flag in an xor group may be set, and at least one flag in a oneof group
+// must be set. The requires option lists, separated by spaces, the names of
+// other flags that must be set whenever this flag is set. These relations are
+// checked by [Fields.Parse]:
+//
+//	JSON bool   `flag:"json,xor=format,Usage string"`
+//	CSV  bool   `flag:"csv,xor=format,Usage string"`
+//	Key  string `flag:"tls-key,requires=tls-cert,Usage string"`
+//
+// The days option allows a time.Duration field to be written with day and
+// week units or in ISO 8601 format, as described by [ParseDuration]; fields of
+// type [Duration] always accept this format. The min and max options bound
+// the values of a field of either type:
+//
+//	flag:"retain,days,min=1d,max=52w,default=2w,Usage string"
+//
+// Types registered with [RegisterType] are also compatible, and the registered
+// parser takes precedence over any of these.
+//
+// The parsed tags of each struct type are cached, so that repeated calls to
+// Check for values of the same type are cheap. Check is safe for concurrent
+// use by multiple goroutines.
 func Check(v any) (Fields, error) {
+	rv, err := structValue(v)
+	if err != nil {
+		return nil, err
+	}
+	return checkValue(rv, checkType(rv.Type()), nil)
+}
+
+// structValue returns the struct value pointed to by v, or an error if v is
+// not a pointer to a struct.
+func structValue(v any) (reflect.Value, error) {
 	if v == nil {
-		return nil, errors.New("value is nil")
+		return reflect.Value{}, errors.New("value is nil")
 	}
 	rp := reflect.ValueOf(v)
 	if rp.Kind() != reflect.Pointer {
-		return nil, errors.New("value is not a pointer")
+		return reflect.Value{}, errors.New("value is not a pointer")
 	}
 	rv := rp.Elem()
 	if rv.Kind() != reflect.Struct {
-		return nil, errors.New("value is not a struct")
+		return reflect.Value{}, errors.New("value is not a struct")
 	}
-	rt := rv.Type()
+	return rv, nil
+}
 
+// checkValue constructs the fields of the struct value rv, whose type-level
+// analysis is ti, consulting reg for registered types. If reg == nil, only the
+// global registry is consulted.
+func checkValue(rv reflect.Value, ti *typeInfo, reg *Registry) (Fields, error) {
 	var fields Fields
-	for i := 0; i < rt.NumField(); i++ {
-		fi, err := parseFieldValue(rt.Field(i), rv.Field(i))
-		if err == errSkipField {
+	var errs FieldErrors
+	next := 0 // index of the next tag error, in field order
+	for _, fs := range ti.fields {
+		for next < len(ti.errs) && ti.errs[next].index < fs.field.Index[0] {
+			errs = append(errs, ti.errs[next].err)
+			next++
+		}
+		fi, err := newField(fs.spec, fs.field, rv.FieldByIndex(fs.field.Index), reg)
+		if err != nil {
+			errs = append(errs, &FieldError{
+				Field: fs.field.Name,
+				Flag:  fs.spec.Name,
+				Tag:   string(fs.field.Tag),
+				Kind:  errorKind(err),
+				Err:   err,
+			})
 			continue
-		} else if err != nil {
-			return nil, fmt.Errorf("field %q: %w", rt.Field(i).Name, err)
 		}
+		fi.owner = rv.Addr().Interface()
 		fields = append(fields, fi)
 	}
-	if len(fields) == 0 {
+	for _, te := range ti.errs[next:] {
+		errs = append(errs, te.err)
+	}
+	names := make(map[string]bool)
+	for _, fs := range ti.fields {
+		names[fs.spec.Name] = true
+	}
+	errs = append(errs, fields.checkRelations(names)...)
+	if len(errs) != 0 {
+		return nil, errs
+	} else if len(fields) == 0 {
 		return nil, errors.New("no flaggable fields")
 	}
 	return fields, nil
@@ -174,6 +323,11 @@ func (f Fields) Flag(s string) *Field {
 			return fi
 		}
 	}
+	if name, ok := strings.CutPrefix(s, negatedPrefix); ok {
+		if fi := f.Flag(name); fi != nil && fi.isNegatable() {
+			return fi
+		}
+	}
 	return nil
 }
 
@@ -183,18 +337,46 @@ func (f Fields) Flag(s string) *Field {
 type Field struct {
 	Name, Usage string // name and usage text (required)
 
-	env    string // environment variable from which default is read
-	dvalue any    // concrete type depends on target
-	target any    // pointer to target field value
+	env    string              // environment variable from which default is read
+	envKey string              // environment variable bound at parse time
+	opts   map[string]string   // options parsed from the field tag
+	field  reflect.StructField // the struct field described
+	dvalue any                 // concrete type depends on target
+	dtext  string              // default value rendered as a string
+	dsnap  reflect.Value       // snapshot of the default value, for Reset
+	base   reflect.Value       // snapshot of the field value before defaults
+	target any                 // pointer to target field value, or a registeredValue
+	owner  any                 // pointer to the enclosing struct, if known
+	reg    *Registry           // registry consulted for the field type
+	reqs   []*Field            // fields named by the requires option
+
+	flag    *flag.Flag    // the flag most recently bound for this field
+	noFlag  *flag.Flag    // the negated form of flag, if negatable
+	flagSet *flag.FlagSet // the flag set to which flag was bound
+	fromEnv bool          // the flag was set from the environment
+	negated bool          // the flag was set by its negated form
 }
 
 // Bind registers the field described by f in the given flag set.
 func (fi *Field) Bind(fs *flag.FlagSet) {
 	usage := fi.Usage
-	if fi.env != "" {
+	if _, ok := fi.opts["count"]; ok {
+		usage += " (repeatable)"
+	}
+	if fi.env != "" && fi.envKey != "" && fi.env != fi.envKey {
+		usage += fmt.Sprintf(" [env: %s, %s]", fi.env, fi.envKey)
+	} else if fi.env != "" {
 		usage += fmt.Sprintf(" [env: %s]", fi.env)
+	} else if fi.envKey != "" {
+		usage += fmt.Sprintf(" [env: %s]", fi.envKey)
 	}
 	switch t := fi.target.(type) {
+	case *registeredValue:
+		fs.Var(t, fi.Name, usage)
+		if reflect.ValueOf(t.target).Elem().IsZero() {
+			fs.Lookup(fi.Name).DefValue = "" // omit the zero value from usage
+		}
+
 	case flag.Value:
 		fs.Var(t, fi.Name, usage)
 
@@ -202,6 +384,11 @@ func (fi *Field) Bind(fs *flag.FlagSet) {
 		fs.TextVar(t, fi.Name, fi.dvalue.(textFlag), usage)
 
 	case *bool:
+		if fi.isNegatable() {
+			*t = fi.dvalue.(bool)
+			fi.bindNegatable(fs, usage)
+			break
+		}
 		fs.BoolVar(t, fi.Name, fi.dvalue.(bool), usage)
 
 	case *float64:
@@ -228,42 +415,214 @@ func (fi *Field) Bind(fs *flag.FlagSet) {
 	default:
 		panic(fmt.Sprintf("cannot flag type %T", t))
 	}
+	fi.flag, fi.flagSet, fi.fromEnv, fi.negated = fs.Lookup(fi.Name), fs, false, false
 }
 
 // Env reports the name of the environment variable used as the default value
 // for fi. It returns "" if the field does not use an environment variable.
 func (fi *Field) Env() string { return fi.env }
 
-var errSkipField = errors.New("skip this field")
+// Type reports the type of the struct field described by fi.
+func (fi *Field) Type() reflect.Type { return fi.field.Type }
+
+// FieldName reports the name of the struct field described by fi.
+func (fi *Field) FieldName() string { return fi.field.Name }
+
+// Index reports the index sequence of the struct field described by fi, as
+// used by [reflect.Value.FieldByIndex].
+func (fi *Field) Index() []int { return slices.Clone(fi.field.Index) }
+
+// Default reports the resolved default value of fi, rendered as a string.
+func (fi *Field) Default() string { return fi.dtext }
+
+// Options reports the options parsed from the flag tag of fi, as a map from
+// option name to value. A default given by a separate flag-default tag is
+// reported as the "default" option. The caller may modify the map without
+// affecting fi.
+func (fi *Field) Options() map[string]string { return maps.Clone(fi.opts) }
+
+// Value returns a [flag.Getter] that reads and writes the target field of fi.
+// Its Set method parses a string as the corresponding flag would, and its Get
+// method returns the current value of the field.
+func (fi *Field) Value() flag.Getter { return fieldValue{fi} }
+
+// fieldValue implements the flag.Getter interface for a field.
+type fieldValue struct{ fi *Field }
+
+func (v fieldValue) String() string {
+	if v.fi == nil {
+		return ""
+	}
+	return v.fi.current()
+}
+
+func (v fieldValue) Set(s string) error { return v.fi.set(s) }
+
+func (v fieldValue) Get() any { return v.fi.get() }
 
-func parseFieldValue(ft reflect.StructField, fv reflect.Value) (*Field, error) {
-	if !ft.IsExported() {
-		return nil, errSkipField // unexported fields are not considered
+func (v fieldValue) IsBoolFlag() bool {
+	switch t := v.fi.target.(type) {
+	case *bool:
+		return true
+	case interface{ IsBoolFlag() bool }:
+		return t.IsBoolFlag()
 	}
-	tag, ok := ft.Tag.Lookup("flag")
+	return false
+}
+
+// A FieldSpec describes the flag for a field, as given by its struct tags.
+type FieldSpec struct {
+	Field   string            // the name of the struct field (optional)
+	Name    string            // the flag name (required)
+	Usage   string            // the flag usage text
+	Options map[string]string // tag options, e.g., "default" and "env"
+}
+
+// ParseTag parses the flag tags of a struct field into a FieldSpec, using the
+// format described by [Check]. It reports false if tag does not contain a flag
+// tag, and an error if the flag tags are not valid. A default value given by a
+// separate flag-default tag is reported as the "default" option. The Field
+// name of the resulting spec is empty.
+func ParseTag(tag reflect.StructTag) (FieldSpec, bool, error) {
+	text, ok := tag.Lookup("flag")
 	if !ok {
-		return nil, errSkipField // un-flagged fields are not considered
+		return FieldSpec{}, false, nil
+	}
+	name, usage, opts, err := parseFieldTag(text)
+	if err != nil {
+		return FieldSpec{}, true, err
+	}
+	if dtag, ok := tag.Lookup("flag-default"); ok {
+		if opts["default"] != "" {
+			return FieldSpec{}, true, errors.New("default tag and string are both set")
+		}
+		if opts == nil {
+			opts = make(map[string]string)
+		}
+		opts["default"] = dtag
+	}
+	return FieldSpec{Name: name, Usage: usage, Options: opts}, true, nil
+}
+
+// NewField constructs a Field for the variable pointed to by target, as
+// described by spec. The type of target must be a pointer to one of the types
+// accepted by [Check], and its default value is resolved as for a struct
+// field with the corresponding tags.
+//
+// NewField allows flag metadata to be constructed without inspecting a struct
+// type. Since a single field has no other flags to relate to, the xor, oneof,
+// and requires options are not accepted; use [NewFields] for those.
+func NewField(target any, spec FieldSpec) (*Field, error) {
+	rp := reflect.ValueOf(target)
+	if rp.Kind() != reflect.Pointer || rp.IsNil() {
+		return nil, errors.New("target is not a non-nil pointer")
+	} else if err := checkSpec(spec); err != nil {
+		return nil, err
+	}
+	for _, key := range []string{"xor", "oneof", "requires"} {
+		if _, ok := spec.Options[key]; ok {
+			return nil, fmt.Errorf("%s option is not supported for a single field", key)
+		}
 	}
-	name, dstring, usage, err := parseFieldTag(tag)
+	spec.Options = maps.Clone(spec.Options)
+	fv := rp.Elem()
+	return newField(spec, reflect.StructField{Name: spec.Field, Type: fv.Type()}, fv, nil)
+}
+
+// NewFields constructs Fields for the fields of the struct pointed to by v,
+// as described by specs, without parsing struct tags. Each spec must give the
+// name of an exported field of v. As for [Check], the relations declared by
+// the xor, oneof, and requires options are checked, the problems found are
+// reported as a [FieldErrors] value, and [Fields.Validate] calls the Validate
+// method of v if it has one.
+//
+// NewFields allows flag metadata to be constructed by generated code. The
+// fields need not be bound: if the flags for the fields of v were bound to a
+// flag set by other means, [Fields.Parse] associates each field with the flag
+// of its name, so that methods such as [Field.IsSet] and [Fields.CheckGroups]
+// report the state of those flags.
+func NewFields(v any, specs ...FieldSpec) (Fields, error) {
+	rv, err := structValue(v)
 	if err != nil {
 		return nil, err
 	}
-	if dtag, ok := ft.Tag.Lookup("flag-default"); ok {
-		if dstring != "" {
-			return nil, errors.New("default tag and string are both set")
+	var fields Fields
+	var errs FieldErrors
+	names := make(map[string]bool)
+	for _, spec := range specs {
+		names[spec.Name] = true
+		ft, ok := rv.Type().FieldByName(spec.Field)
+		if !ok || !ft.IsExported() {
+			err = fmt.Errorf("no exported field %q", spec.Field)
+		} else if err = checkSpec(spec); err == nil {
+			spec.Options = maps.Clone(spec.Options)
+			var fi *Field
+			if fi, err = newField(spec, ft, rv.FieldByIndex(ft.Index), nil); err == nil {
+				fi.owner = v
+				fields = append(fields, fi)
+				continue
+			}
+		}
+		errs = append(errs, &FieldError{
+			Field: spec.Field,
+			Flag:  spec.Name,
+			Kind:  errorKind(err),
+			Err:   err,
+		})
+	}
+	errs = append(errs, fields.checkRelations(names)...)
+	if len(errs) != 0 {
+		return nil, errs
+	}
+	return fields, nil
+}
+
+// checkSpec reports whether spec has a flag name and only known options.
+func checkSpec(spec FieldSpec) error {
+	if spec.Name == "" {
+		return errors.New("empty flag name")
+	}
+	for key := range spec.Options {
+		if _, ok := tagOptions[key]; !ok {
+			return fmt.Errorf("unknown option %q", key)
 		}
-		dstring = dtag
 	}
+	return nil
+}
 
+// newField constructs a Field for the value fv, described by spec and ft.
+// Types registered in reg or the global registry take precedence.
+func newField(spec FieldSpec, ft reflect.StructField, fv reflect.Value, reg *Registry) (*Field, error) {
+	dstring := spec.Options["default"]
 	vptr := fv.Addr().Interface()
 	info := &Field{
-		Name:   name,
-		Usage:  usage,
+		Name:   spec.Name,
+		Usage:  spec.Usage,
+		envKey: spec.Options["env"],
+		opts:   spec.Options,
+		field:  ft,
+		base:   copyValue(fv),
 		target: vptr,
+		reg:    reg,
+	}
+	if err := checkOptionTypes(spec.Options, fv.Type()); err != nil {
+		return nil, err
+	}
+	if _, ok := spec.Options["count"]; ok {
+		if _, ok := spec.Options["size"]; ok {
+			return nil, errors.New("count and size options are mutually exclusive")
+		}
+		info.target = &registeredValue{target: vptr, codec: counterCodec()}
+	} else if _, ok := spec.Options["size"]; ok {
+		info.target = &registeredValue{target: vptr, codec: sizeCodec(vptr)}
+	} else if c, err := reg.lookup(fv.Type(), spec.Options); err != nil {
+		return nil, err
+	} else if c != nil {
+		info.target = &registeredValue{target: vptr, codec: c}
 	}
 
 	// Check for compatible type.
-	switch t := vptr.(type) {
+	switch t := info.target.(type) {
 	case *bool:
 		d, err := parseDefault(info, dstring, *t, strconv.ParseBool)
 		if err != nil {
@@ -272,9 +631,7 @@ func parseFieldValue(ft reflect.StructField, fv reflect.Value) (*Field, error) {
 		info.dvalue = d
 
 	case *float64:
-		d, err := parseDefault(info, dstring, *t, func(s string) (float64, error) {
-			return strconv.ParseFloat(s, 64)
-		})
+		d, err := parseDefault(info, dstring, *t, parseFloat64)
 		if err != nil {
 			return nil, err
 		}
@@ -288,9 +645,7 @@ func parseFieldValue(ft reflect.StructField, fv reflect.Value) (*Field, error) {
 		info.dvalue = d
 
 	case *int64:
-		d, err := parseDefault(info, dstring, *t, func(s string) (int64, error) {
-			return strconv.ParseInt(s, 10, 64)
-		})
+		d, err := parseDefault(info, dstring, *t, parseInt64)
 		if err != nil {
 			return nil, err
 		}
@@ -320,19 +675,14 @@ func parseFieldValue(ft reflect.StructField, fv reflect.Value) (*Field, error) {
 		info.dvalue = d
 
 	case *uint:
-		d, err := parseDefault(info, dstring, *t, func(s string) (uint, error) {
-			u, err := strconv.ParseUint(s, 10, 64)
-			return uint(u), err
-		})
+		d, err := parseDefault(info, dstring, *t, parseUint)
 		if err != nil {
 			return nil, err
 		}
 		info.dvalue = d
 
 	case *uint64:
-		d, err := parseDefault(info, dstring, *t, func(s string) (uint64, error) {
-			return strconv.ParseUint(s, 10, 64)
-		})
+		d, err := parseDefault(info, dstring, *t, parseUint64)
 		if err != nil {
 			return nil, err
 		}
@@ -345,40 +695,116 @@ func parseFieldValue(ft reflect.StructField, fv reflect.Value) (*Field, error) {
 		if err != nil {
 			return nil, err
 		}
+		if err := checkBounds(info); err != nil {
+			return nil, err
+		}
 		info.dvalue = t
 
 	default:
-		return nil, fmt.Errorf("type %T is not flag compatible", t)
+		return nil, kindError{KindType, fmt.Errorf("type %T is not flag compatible", t)}
+	}
+	info.dtext = formatValue(info.dvalue)
+	if info.dvalue == info.target {
+		info.dsnap = copyValue(fv) // the default is the state of the target
+	} else {
+		info.dsnap = reflect.ValueOf(info.dvalue)
 	}
 
 	return info, nil
 }
 
-// Quoted default: ' ... ', allows "," and single quotes (as ”).
-// Plain default:  ..., no "," or single quotes.
-var defaultRE = regexp.MustCompile(`^('(?:[^']|'')*'|[^,']*),(.*)$`)
+// A tag option is "key" or "key=V" followed by a comma. The value V may be
+// quoted: ' ... ', allowing "," and doubled single quotes. An unquoted value
+// may not contain "," or single quotes.
+var optionRE = regexp.MustCompile(`^([a-z][-a-z]*)(?:=('(?:[^']|'')*'|[^,']*))?,(.*)$`)
+
+// tagOptions records the options understood in a flag tag, and whether each
+// requires a value.
+var tagOptions = map[string]bool{
+	"count":      false,
+	"days":       false,
+	"default":    true,
+	"env":        true,
+	"layout":     true,
+	"max":        true,
+	"min":        true,
+	"mutable":    false,
+	"negatable":  false,
+	"oneof":      true,
+	"reloadable": false,
+	"requires":   true,
+	"secret":     false,
+	"size":       false,
+	"xor":        true,
+}
+
+// optionTypes records the field types to which tag options are restricted.
+// Options not listed apply to fields of any type.
+var optionTypes = map[string][]reflect.Type{
+	"count": {
+		reflect.TypeFor[int](), reflect.TypeFor[int64](),
+		reflect.TypeFor[uint](), reflect.TypeFor[uint64](),
+	},
+	"days":      {reflect.TypeFor[time.Duration]()},
+	"layout":    {reflect.TypeFor[time.Time]()},
+	"max":       {reflect.TypeFor[time.Duration](), reflect.TypeFor[Duration]()},
+	"min":       {reflect.TypeFor[time.Duration](), reflect.TypeFor[Duration]()},
+	"negatable": {reflect.TypeFor[bool]()},
+	"size":      {reflect.TypeFor[int64](), reflect.TypeFor[uint64]()},
+}
+
+// checkOptionTypes reports an error if any of opts does not apply to a field
+// of type ft.
+func checkOptionTypes(opts map[string]string, ft reflect.Type) error {
+	for _, key := range slices.Sorted(maps.Keys(opts)) {
+		if types, ok := optionTypes[key]; ok && !slices.Contains(types, ft) {
+			names := make([]string, len(types))
+			for i, t := range types {
+				names[i] = t.String()
+			}
+			return fmt.Errorf("%s option requires a field of type %s", key, strings.Join(names, " or "))
+		}
+	}
+	return nil
+}
 
-func parseFieldTag(s string) (name, dstring, usage string, _ error) {
+func parseFieldTag(s string) (name, usage string, opts map[string]string, _ error) {
 	// Simple format: "name,usage"
-	// Default format: "name,default=V,usage"
+	// Option format: "name,key=V,...,usage"
 
 	name, usage, ok := strings.Cut(s, ",")
 	if !ok {
-		return "", "", "", fmt.Errorf("invalid flag tag format %q", s)
+		return "", "", nil, fmt.Errorf("invalid flag tag format %q", s)
 	}
 
-	if d, ok := strings.CutPrefix(usage, "default="); ok {
-		m := defaultRE.FindStringSubmatch(d)
+	for {
+		m := optionRE.FindStringSubmatch(usage)
 		if m == nil {
-			return "", "", "", fmt.Errorf("invalid default format %q", d)
+			key, _, ok := strings.Cut(usage, "=")
+			if ok && tagOptions[key] {
+				return "", "", nil, fmt.Errorf("invalid %s format %q", key, usage[len(key)+1:])
+			}
+			break
+		}
+		key, val := m[1], m[2]
+		wantValue, known := tagOptions[key]
+		if !known || wantValue != strings.HasPrefix(usage, key+"=") {
+			break // not an option; this is the start of the usage text
 		}
-		dstring, usage = m[1], m[2]
-		if strings.HasPrefix(dstring, "'") {
-			dstring = strings.ReplaceAll(dstring[1:len(dstring)-1], "''", "'") // remove 'quotations'
+		if _, ok := opts[key]; ok {
+			return "", "", nil, fmt.Errorf("duplicate %s option", key)
 		}
+		if strings.HasPrefix(val, "'") {
+			val = strings.ReplaceAll(val[1:len(val)-1], "''", "'") // remove 'quotations'
+		}
+		if opts == nil {
+			opts = make(map[string]string)
+		}
+		opts[key] = val
+		usage = m[3]
 	}
 	if name == "" {
-		return "", "", "", errors.New("empty flag name")
+		return "", "", nil, errors.New("empty flag name")
 	}
 	return
 }
@@ -400,12 +826,118 @@ func parseDefault[T any](f *Field, s string, self T, parse func(string) (T, erro
 	}
 	v, err := parse(s)
 	if err != nil {
-		return zero, fmt.Errorf("invalid default for %q: %w", f.Name, err)
+		kind := KindDefault
+		if f.env != "" {
+			kind = KindEnv
+		}
+		return zero, kindError{kind, fmt.Errorf("invalid default for %q: %w", f.Name, err)}
 	}
 	return v, nil
 }
 
+// checkBounds reports an error if f has a min or max option and the value of
+// its target, as resolved from its default, is outside those bounds. This
+// catches an omitted default whose zero value is out of range, which parsing
+// the default does not.
+func checkBounds(f *Field) error {
+	_, hasMin := f.opts["min"]
+	_, hasMax := f.opts["max"]
+	rv, ok := f.target.(*registeredValue)
+	if !ok || !hasMin && !hasMax {
+		return nil
+	}
+	if err := rv.Set(rv.String()); err != nil {
+		return kindError{KindDefault, fmt.Errorf("invalid default for %q: %w", f.Name, err)}
+	}
+	return nil
+}
+
 type textFlag interface {
 	MarshalText() ([]byte, error)
 	UnmarshalText([]byte) error
 }
+
+// set parses s and stores the resulting value in the target of fi.
+func (fi *Field) set(s string) error {
+	var err error
+	switch t := fi.target.(type) {
+	case flag.Value:
+		return t.Set(s)
+	case textFlag:
+		return t.UnmarshalText([]byte(s))
+	case *bool:
+		*t, err = strconv.ParseBool(s)
+	case *float64:
+		*t, err = parseFloat64(s)
+	case *int:
+		*t, err = strconv.Atoi(s)
+	case *int64:
+		*t, err = parseInt64(s)
+	case *string:
+		*t = s
+	case *time.Duration:
+		*t, err = time.ParseDuration(s)
+	case *uint:
+		*t, err = parseUint(s)
+	case *uint64:
+		*t, err = parseUint64(s)
+	default:
+		panic(fmt.Sprintf("cannot flag type %T", t))
+	}
+	return err
+}
+
+// get returns the current value of the target of fi.
+func (fi *Field) get() any {
+	switch t := fi.target.(type) {
+	case flag.Getter:
+		return t.Get()
+	case flag.Value, textFlag:
+		return t
+	}
+	return reflect.ValueOf(fi.target).Elem().Interface()
+}
+
+func parseFloat64(s string) (float64, error) { return strconv.ParseFloat(s, 64) }
+func parseInt64(s string) (int64, error)     { return strconv.ParseInt(s, 10, 64) }
+func parseUint64(s string) (uint64, error)   { return strconv.ParseUint(s, 10, 64) }
+
+func parseUint(s string) (uint, error) {
+	u, err := strconv.ParseUint(s, 10, strconv.IntSize)
+	return uint(u), err
+}
+
+// targetValue returns the target field of fi.
+func (fi *Field) targetValue() reflect.Value {
+	if rv, ok := fi.target.(*registeredValue); ok {
+		return reflect.ValueOf(rv.target).Elem()
+	}
+	return reflect.ValueOf(fi.target).Elem()
+}
+
+// current renders the current value of the target of fi as a string.
+func (fi *Field) current() string {
+	switch fi.target.(type) {
+	case flag.Value, textFlag:
+		return formatValue(fi.target)
+	}
+	return formatValue(reflect.ValueOf(fi.target).Elem().Interface())
+}
+
+// formatValue renders a flag value as a string.
+func formatValue(v any) string {
+	switch t := v.(type) {
+	case flag.Value:
+		return t.String()
+	case textFlag:
+		text, err := t.MarshalText()
+		if err != nil {
+			return ""
+		}
+		return string(text)
+	case float64:
+		return strconv.FormatFloat(t, 'g', -1, 64)
+	default:
+		return fmt.Sprint(t)
+	}
+}
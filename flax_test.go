@@ -4,12 +4,19 @@ package flax_test
 
 import (
 	"bytes"
+	"errors"
 	"flag"
+	"fmt"
+	"io"
 	"log"
 	"os"
 	"reflect"
+	"slices"
+	"strconv"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/creachadair/flax"
 )
@@ -115,6 +122,14 @@ func TestCheckError(t *testing.T) {
 		{"empty name", &struct {
 			S string `flag:",empty name"`
 		}{}},
+
+		{"duplicate option", &struct {
+			S string `flag:"s,env=A,env=B,duplicate"`
+		}{}},
+
+		{"bad option quotes", &struct {
+			S string `flag:"s,env='A,bad quotes"`
+		}{}},
 	}
 	for _, tc := range tests {
 		t.Run(tc.label, func(t *testing.T) {
@@ -422,3 +437,323 @@ func TestPreferValueToText(t *testing.T) {
 		t.Errorf("Text flag: got %q, want empty", got)
 	}
 }
+
+func TestFieldInfo(t *testing.T) {
+	var flags struct {
+		Skip  bool
+		Count int       `flag:"count,default=3,env=COUNT,Count"`
+		Label string    `flag:"label,Label" flag-default:"a, b"`
+		Text  textFlag  `flag:"text,default=hello,Text"`
+		Value flagValue `flag:"value,Value"`
+	}
+	fs, err := flax.Check(&flags)
+	if err != nil {
+		t.Fatalf("Check failed: %v", err)
+	}
+
+	tests := []struct {
+		name, field string
+		index       []int
+		typ         reflect.Type
+		dvalue      string
+		opts        map[string]string
+	}{
+		{"count", "Count", []int{1}, reflect.TypeOf(0), "3",
+			map[string]string{"default": "3", "env": "COUNT"}},
+		{"label", "Label", []int{2}, reflect.TypeOf(""), "a, b",
+			map[string]string{"default": "a, b"}},
+		{"text", "Text", []int{3}, reflect.TypeOf(textFlag{}), "hello",
+			map[string]string{"default": "hello"}},
+		{"value", "Value", []int{4}, reflect.TypeOf(flagValue{}), "", nil},
+	}
+	for _, tc := range tests {
+		fi := fs.Flag(tc.name)
+		if fi == nil {
+			t.Fatalf("Flag %q not found", tc.name)
+		}
+		if got := fi.FieldName(); got != tc.field {
+			t.Errorf("Flag %q field name: got %q, want %q", tc.name, got, tc.field)
+		}
+		if got := fi.Index(); !reflect.DeepEqual(got, tc.index) {
+			t.Errorf("Flag %q index: got %v, want %v", tc.name, got, tc.index)
+		}
+		if got := fi.Type(); got != tc.typ {
+			t.Errorf("Flag %q type: got %v, want %v", tc.name, got, tc.typ)
+		}
+		if got := fi.Default(); got != tc.dvalue {
+			t.Errorf("Flag %q default: got %q, want %q", tc.name, got, tc.dvalue)
+		}
+		if got := fi.Options(); len(got) != 0 || len(tc.opts) != 0 {
+			if !reflect.DeepEqual(got, tc.opts) {
+				t.Errorf("Flag %q options: got %v, want %v", tc.name, got, tc.opts)
+			}
+		}
+	}
+}
+
+func TestFieldValue(t *testing.T) {
+	var flags struct {
+		B bool      `flag:"b,Bool"`
+		Z int       `flag:"z,default=5,Int"`
+		S string    `flag:"s,String"`
+		T textFlag  `flag:"t,Text"`
+		V flagValue `flag:"v,Value"`
+	}
+	fs := flax.MustCheck(&flags)
+
+	for _, tc := range []struct {
+		name, input string
+		want        any
+	}{
+		{"b", "true", true},
+		{"z", "17", 17},
+		{"s", "apple pie", "apple pie"},
+	} {
+		v := fs.Flag(tc.name).Value()
+		if err := v.Set(tc.input); err != nil {
+			t.Errorf("Set %q: unexpected error: %v", tc.input, err)
+		} else if got := v.Get(); got != tc.want {
+			t.Errorf("Get %q: got %v, want %v", tc.name, got, tc.want)
+		} else if got := v.String(); got != tc.input {
+			t.Errorf("String %q: got %q, want %q", tc.name, got, tc.input)
+		}
+	}
+	if err := fs.Flag("z").Value().Set("bogus"); err == nil {
+		t.Error("Set z: got nil, want error")
+	}
+	if err := fs.Flag("t").Value().Set("text"); err != nil {
+		t.Errorf("Set t: unexpected error: %v", err)
+	} else if flags.T.value != "text" {
+		t.Errorf("Set t: got %q, want text", flags.T.value)
+	}
+	if err := fs.Flag("v").Value().Set("value"); err != nil {
+		t.Errorf("Set v: unexpected error: %v", err)
+	} else if flags.V.value != "value" {
+		t.Errorf("Set v: got %q, want value", flags.V.value)
+	}
+
+	// The value should report itself as a boolean flag when appropriate.
+	type boolFlag interface{ IsBoolFlag() bool }
+	if bf, ok := fs.Flag("b").Value().(boolFlag); !ok || !bf.IsBoolFlag() {
+		t.Error("Flag b value is not a boolean flag")
+	}
+	if bf, ok := fs.Flag("z").Value().(boolFlag); ok && bf.IsBoolFlag() {
+		t.Error("Flag z value is unexpectedly a boolean flag")
+	}
+
+	var custom struct {
+		On toggleFlag `flag:"on,Custom boolean"`
+	}
+	if bf, ok := flax.MustCheck(&custom).Flag("on").Value().(boolFlag); !ok || !bf.IsBoolFlag() {
+		t.Error("Flag on value is not a boolean flag")
+	}
+}
+
+// toggleFlag is a custom flag.Value that reports itself as a boolean flag.
+type toggleFlag bool
+
+func (f toggleFlag) String() string { return fmt.Sprint(bool(f)) }
+
+func (f *toggleFlag) Set(s string) error {
+	b, err := strconv.ParseBool(s)
+	*f = toggleFlag(b)
+	return err
+}
+
+func (toggleFlag) IsBoolFlag() bool { return true }
+
+func TestNewField(t *testing.T) {
+	spec, ok, err := flax.ParseTag(`flag:"count,env=N,Count" flag-default:"4"`)
+	if !ok || err != nil {
+		t.Fatalf("ParseTag: got (%v, %v), want (true, nil)", ok, err)
+	}
+	want := flax.FieldSpec{Name: "count", Usage: "Count", Options: map[string]string{"default": "4", "env": "N"}}
+	if !reflect.DeepEqual(spec, want) {
+		t.Errorf("ParseTag: got %+v, want %+v", spec, want)
+	}
+	if _, ok, _ := flax.ParseTag(`json:"count"`); ok {
+		t.Error("ParseTag without flag tag: got true, want false")
+	}
+
+	var count int
+	fi, err := flax.NewField(&count, spec)
+	if err != nil {
+		t.Fatalf("NewField: unexpected error: %v", err)
+	}
+	fs := flag.NewFlagSet("test", flag.PanicOnError)
+	fi.Bind(fs)
+	if count != 4 {
+		t.Errorf("Count: got %d, want 4", count)
+	}
+	if got := fi.EnvVar(); got != "N" {
+		t.Errorf("EnvVar: got %q, want N", got)
+	}
+
+	for _, tc := range []struct {
+		target any
+		spec   flax.FieldSpec
+	}{
+		{count, flax.FieldSpec{Name: "x"}},
+		{new(int), flax.FieldSpec{}},
+		{new(int), flax.FieldSpec{Name: "x", Options: map[string]string{"bogus": ""}}},
+		{new(int), flax.FieldSpec{Name: "x", Options: map[string]string{"default": "x"}}},
+		{new([]byte), flax.FieldSpec{Name: "x"}},
+		{new(int), flax.FieldSpec{Name: "x", Options: map[string]string{"requires": "y"}}},
+		{new(bool), flax.FieldSpec{Name: "x", Options: map[string]string{"xor": "g"}}},
+	} {
+		if fi, err := flax.NewField(tc.target, tc.spec); err == nil {
+			t.Errorf("NewField(%T, %+v): got %+v, want error", tc.target, tc.spec, fi)
+		}
+	}
+}
+
+func TestNewFields(t *testing.T) {
+	var v struct {
+		Key  string
+		Cert string
+		Mode int
+	}
+	fields, err := flax.NewFields(&v,
+		flax.FieldSpec{Field: "Key", Name: "key", Usage: "Key", Options: map[string]string{"requires": "cert"}},
+		flax.FieldSpec{Field: "Cert", Name: "cert", Usage: "Certificate"},
+		flax.FieldSpec{Field: "Mode", Name: "mode", Usage: "Mode", Options: map[string]string{"default": "3"}},
+	)
+	if err != nil {
+		t.Fatalf("NewFields: unexpected error: %v", err)
+	}
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	fields.Bind(fs)
+	if v.Mode != 3 {
+		t.Errorf("Mode: got %d, want 3", v.Mode)
+	}
+	if err := fields.Parse(fs, []string{"-key", "k"}); err == nil || err.Error() != "flag -key requires -cert" {
+		t.Errorf("Parse: got %v, want requires error", err)
+	}
+
+	_, err = flax.NewFields(&v,
+		flax.FieldSpec{Field: "Key", Name: "key", Usage: "Key", Options: map[string]string{"requires": "other"}},
+		flax.FieldSpec{Field: "Nope", Name: "nope", Usage: "No such field"},
+		flax.FieldSpec{Field: "Mode", Name: "mode", Usage: "Mode", Options: map[string]string{"default": "x"}},
+	)
+	var fe flax.FieldErrors
+	if !errors.As(err, &fe) {
+		t.Fatalf("NewFields: got %v, want FieldErrors", err)
+	}
+	var got []string
+	for _, e := range fe {
+		got = append(got, e.Field)
+	}
+	if want := []string{"Nope", "Mode", "Key"}; !slices.Equal(got, want) {
+		t.Errorf("NewFields errors: got fields %q, want %q\n%v", got, want, err)
+	}
+}
+
+func TestNewFieldsUnbound(t *testing.T) {
+	var v struct {
+		Key   string
+		Cert  string
+		Cache bool
+	}
+	specs := []flax.FieldSpec{
+		{Field: "Key", Name: "key", Usage: "Key", Options: map[string]string{"requires": "cert"}},
+		{Field: "Cert", Name: "cert", Usage: "Certificate"},
+		{Field: "Cache", Name: "cache", Usage: "Cache", Options: map[string]string{"negatable": ""}},
+	}
+	for _, tc := range []struct {
+		args    []string
+		wantErr string
+		set     []string
+	}{
+		{nil, "", nil},
+		{[]string{"-key", "k"}, "flag -key requires -cert", []string{"key"}},
+		{[]string{"-key", "k", "-cert", "c", "-no-cache"}, "", []string{"key", "cert", "cache"}},
+	} {
+		// Bind the flags separately from the fields used to parse them, as
+		// the code generated by flaxgen does.
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		fs.SetOutput(io.Discard)
+		fs.StringVar(&v.Key, "key", "", "Key")
+		fs.StringVar(&v.Cert, "cert", "", "Certificate")
+		fi, err := flax.NewField(&v.Cache, specs[2])
+		if err != nil {
+			t.Fatalf("NewField: unexpected error: %v", err)
+		}
+		fi.Bind(fs)
+
+		fields, err := flax.NewFields(&v, specs...)
+		if err != nil {
+			t.Fatalf("NewFields: unexpected error: %v", err)
+		}
+		err = fields.Parse(fs, tc.args)
+		if tc.wantErr == "" && err != nil {
+			t.Errorf("Parse %q: unexpected error: %v", tc.args, err)
+		} else if tc.wantErr != "" && (err == nil || err.Error() != tc.wantErr) {
+			t.Errorf("Parse %q: got %v, want %q", tc.args, err, tc.wantErr)
+		}
+		var set []string
+		for _, fi := range fields.Changed() {
+			set = append(set, fi.Name)
+		}
+		if !slices.Equal(set, tc.set) {
+			t.Errorf("Parse %q: changed flags %q, want %q", tc.args, set, tc.set)
+		}
+		if got, want := fields.Flag("cache").Negated(), slices.Contains(tc.args, "-no-cache"); got != want {
+			t.Errorf("Parse %q: Negated: got %v, want %v", tc.args, got, want)
+		}
+	}
+}
+
+type benchFlags struct {
+	Input   string        `flag:"input,Input file name"`
+	Output  string        `flag:"output,default=out.txt,Output file name"`
+	DryRun  bool          `flag:"dry-run,Dry run, do not make any changes"`
+	Count   int           `flag:"count,default=1,Number of iterations"`
+	Limit   int64         `flag:"limit,default=*,Limit on results"`
+	Rate    float64       `flag:"rate,default=0.25,Rate of increase"`
+	Timeout time.Duration `flag:"timeout,env=TIMEOUT,Timeout" flag-default:"30s"`
+	Label   string        `flag:"label,default='a, b, c',Label text"`
+	Ignored int
+}
+
+func TestCheckConcurrent(t *testing.T) {
+	var wg sync.WaitGroup
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+		go func(limit int64) {
+			defer wg.Done()
+			v := benchFlags{Limit: limit}
+			fi, err := flax.Check(&v)
+			if err != nil {
+				t.Errorf("Check failed: %v", err)
+				return
+			}
+			if got, want := fi.Flag("limit").Default(), strconv.FormatInt(limit, 10); got != want {
+				t.Errorf("Limit default: got %q, want %q", got, want)
+			}
+		}(int64(i))
+	}
+	wg.Wait()
+}
+
+func BenchmarkCheck(b *testing.B) {
+	b.Run("Cached", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			var v benchFlags
+			if _, err := flax.Check(&v); err != nil {
+				b.Fatalf("Check failed: %v", err)
+			}
+		}
+	})
+	b.Run("Uncached", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			flax.ClearTypeCache()
+			var v benchFlags
+			if _, err := flax.Check(&v); err != nil {
+				b.Fatalf("Check failed: %v", err)
+			}
+		}
+	})
+}
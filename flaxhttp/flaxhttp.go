@@ -0,0 +1,327 @@
+// Copyright (C) 2023 Michael J. Fromberger. All Rights Reserved.
+
+// Package flaxhttp provides an HTTP handler for viewing and changing the
+// values of flags described by [flax.Fields] in a running program.
+//
+// A GET request to the handler returns the current values of the flags, as
+// JSON if the request has "format=json" in its query or accepts
+// application/json, and as an HTML page otherwise. A POST request with form
+// values of the form "name=value" sets the named flags, which must be tagged
+// with the mutable option. Values are parsed in the same way as on the
+// command line. After the values are set, the handler calls the validation
+// hooks of the fields (see [flax.Fields.Validate]); if they report an error,
+// the update is undone. The values of flags tagged with the secret option are
+// redacted in all responses and in the audit log.
+//
+// To protect against cross-site request forgery, a POST request must either
+// include the [RequestHeader] header, which a browser does not send on a
+// cross-site form submission, or come from a form on the page served by the
+// handler, which carries a token unique to the handler.
+//
+// The [Publish] function exports the values of flags via the [expvar] package.
+//
+// The handler does not perform authentication; callers should wrap it in
+// suitable access controls before exposing it.
+package flaxhttp
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"expvar"
+	"fmt"
+	"html/template"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/creachadair/flax"
+)
+
+// Redacted is the text reported in place of the value of a secret flag.
+const Redacted = flax.Redacted
+
+// RequestHeader is the name of an HTTP header that a POST request must have,
+// with a non-empty value, unless it is submitted from the handler's page.
+const RequestHeader = "X-Flax-Request"
+
+// Publish publishes the current values of the fields of h as an [expvar.Var]
+// with the given name, so that they are reported by the /debug/vars handler.
+// The values are read under the same lock as updates made by h. The values of
+// secret fields are redacted. Like [expvar.Publish], it panics if name is
+// already registered.
+func Publish(name string, h *Handler) { expvar.Publish(name, publishedVar{h}) }
+
+// A publishedVar is an [expvar.Var] reporting the values of a handler.
+type publishedVar struct{ h *Handler }
+
+func (v publishedVar) String() string {
+	v.h.mu.Lock()
+	defer v.h.mu.Unlock()
+	return flax.Values{Fields: v.h.fields}.String()
+}
+
+// A Handler is an [http.Handler] that serves the values of a collection of
+// flags, and accepts updates to the values of mutable flags.
+//
+// The handler serializes its own reads and updates of flag values under a
+// lock. The program must synchronize its other uses of the underlying struct
+// fields with updates made by the handler, for example by reading them in a
+// function passed to [Handler.Do].
+type Handler struct {
+	fields flax.Fields
+	token  string // the request token embedded in the page
+
+	// OnChange, if non-nil, is called synchronously for each change made by
+	// the handler, after it has been recorded in the audit log. It is called
+	// with the lock of the handler held, so it must not call [Handler.Do].
+	OnChange func(Change)
+
+	mu    sync.Mutex
+	audit []Change
+}
+
+// A Change records a change made to the value of a flag by a [Handler].
+type Change struct {
+	Time   time.Time `json:"time"`
+	User   string    `json:"user,omitempty"` // from HTTP basic auth, if present
+	Remote string    `json:"remote"`         // remote address of the request
+	Flag   string    `json:"flag"`           // the name of the flag changed
+	Old    string    `json:"old"`            // the previous value (redacted if secret)
+	New    string    `json:"new"`            // the new value (redacted if secret)
+}
+
+// NewHandler constructs a Handler for the specified fields, which should be
+// bound to the flags of the program.
+func NewHandler(fields flax.Fields) *Handler {
+	var buf [16]byte
+	rand.Read(buf[:])
+	return &Handler{fields: fields, token: hex.EncodeToString(buf[:])}
+}
+
+// Do calls f while holding the lock under which h reads and updates the values
+// of its flags, so that f may safely read or modify the underlying struct
+// fields. While f runs, no update made by h is partly applied. Since it holds
+// the lock, f must not call methods of h or read the values published for h by
+// [Publish].
+func (h *Handler) Do(f func()) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	f()
+}
+
+// Audit returns a copy of the log of changes made by h, in order of
+// occurrence.
+func (h *Handler) Audit() []Change {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return append([]Change(nil), h.audit...)
+}
+
+// ServeHTTP implements the [http.Handler] interface.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet, http.MethodHead:
+		h.serveValues(w, r)
+	case http.MethodPost:
+		h.serveUpdate(w, r)
+	default:
+		w.Header().Set("Allow", "GET, HEAD, POST")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// A flagInfo is the JSON encoding of a flag and its current value.
+type flagInfo struct {
+	Name    string `json:"name"`
+	Value   string `json:"value"`
+	Default string `json:"default"`
+	Usage   string `json:"usage"`
+	Mutable bool   `json:"mutable,omitempty"`
+	Secret  bool   `json:"secret,omitempty"`
+}
+
+func (h *Handler) values() []flagInfo {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	out := make([]flagInfo, len(h.fields))
+	for i, fi := range h.fields {
+		opts := fi.Options()
+		_, mutable := opts["mutable"]
+		_, secret := opts["secret"]
+		out[i] = flagInfo{
+			Name:    fi.Name,
+			Value:   redact(secret, fi.Value().String()),
+			Default: redact(secret, fi.Default()),
+			Usage:   fi.Usage,
+			Mutable: mutable,
+			Secret:  secret,
+		}
+	}
+	return out
+}
+
+func (h *Handler) serveValues(w http.ResponseWriter, r *http.Request) {
+	vals := h.values()
+	if wantJSON(r) {
+		writeJSON(w, http.StatusOK, vals)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	page := struct {
+		Token string
+		Flags []flagInfo
+	}{h.token, vals}
+	if err := pageTemplate.Execute(w, page); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func (h *Handler) serveUpdate(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	token, fromPage := r.PostForm["_token"]
+	delete(r.PostForm, "_token")
+	if fromPage {
+		if len(token) != 1 || subtle.ConstantTimeCompare([]byte(token[0]), []byte(h.token)) != 1 {
+			http.Error(w, "invalid request token", http.StatusForbidden)
+			return
+		}
+	} else if r.Header.Get(RequestHeader) == "" {
+		http.Error(w, fmt.Sprintf("missing %s header", RequestHeader), http.StatusForbidden)
+		return
+	}
+	if len(r.PostForm) == 0 {
+		http.Error(w, "no flags specified", http.StatusBadRequest)
+		return
+	}
+
+	// Check that all the requested flags exist and are mutable before making
+	// any changes.
+	type update struct {
+		fi    *flax.Field
+		value string
+	}
+	var updates []update
+	for _, fi := range h.fields {
+		vals, ok := r.PostForm[fi.Name]
+		if !ok {
+			continue
+		} else if _, ok := fi.Options()["mutable"]; !ok {
+			http.Error(w, fmt.Sprintf("flag %q is not mutable", fi.Name), http.StatusForbidden)
+			return
+		} else if len(vals) != 1 {
+			http.Error(w, fmt.Sprintf("flag %q has multiple values", fi.Name), http.StatusBadRequest)
+			return
+		}
+		updates = append(updates, update{fi: fi, value: vals[0]})
+	}
+	if len(updates) != len(r.PostForm) {
+		for name := range r.PostForm {
+			if fi := h.fields.Flag(name); fi == nil {
+				http.Error(w, fmt.Sprintf("unknown flag %q", name), http.StatusNotFound)
+				return
+			} else if fi.Name != name {
+				http.Error(w, fmt.Sprintf("flag %q must be set as %q", name, fi.Name), http.StatusBadRequest)
+				return
+			}
+		}
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	// Apply the updates, restoring the original values if any fails or the
+	// result is not valid.
+	old := make([]string, len(updates))
+	snaps := make([]func(), len(updates))
+	restore := func() {
+		for _, f := range snaps {
+			if f != nil {
+				f()
+			}
+		}
+	}
+	for i, u := range updates {
+		v := u.fi.Value()
+		old[i] = v.String()
+		snaps[i] = u.fi.Snapshot()
+		if err := v.Set(u.value); err != nil {
+			restore()
+			http.Error(w, fmt.Sprintf("invalid value for flag %q: %v", u.fi.Name, err), http.StatusBadRequest)
+			return
+		}
+	}
+	if err := h.fields.Validate(); err != nil {
+		restore()
+		http.Error(w, fmt.Sprintf("invalid flag values: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	user, _, _ := r.BasicAuth()
+	now := time.Now()
+	var changes []Change
+	for i, u := range updates {
+		_, secret := u.fi.Options()["secret"]
+		c := Change{
+			Time:   now,
+			User:   user,
+			Remote: r.RemoteAddr,
+			Flag:   u.fi.Name,
+			Old:    redact(secret, old[i]),
+			New:    redact(secret, u.fi.Value().String()),
+		}
+		h.audit = append(h.audit, c)
+		changes = append(changes, c)
+		if h.OnChange != nil {
+			h.OnChange(c)
+		}
+	}
+	if fromPage {
+		http.Redirect(w, r, r.URL.Path, http.StatusSeeOther)
+		return
+	}
+	writeJSON(w, http.StatusOK, changes)
+}
+
+func redact(secret bool, s string) string {
+	if secret {
+		return Redacted
+	}
+	return s
+}
+
+func wantJSON(r *http.Request) bool {
+	return r.URL.Query().Get("format") == "json" ||
+		strings.Contains(r.Header.Get("Accept"), "application/json")
+}
+
+func writeJSON(w http.ResponseWriter, code int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.Encode(v)
+}
+
+var pageTemplate = template.Must(template.New("page").Parse(`<!DOCTYPE html>
+<html><head><title>Flags</title></head>
+<body>
+<h1>Flags</h1>
+<table>
+<tr><th>Name</th><th>Value</th><th>Default</th><th>Usage</th></tr>
+{{range .Flags}}<tr>
+<td><code>-{{.Name}}</code></td>
+<td>{{if .Mutable}}<form method="POST"><input type="hidden" name="_token" value="{{$.Token}}">
+<input name="{{.Name}}" value="{{if not .Secret}}{{.Value}}{{end}}"{{if .Secret}} type="password"{{end}}>
+<input type="submit" value="Set"></form>{{else}}<code>{{.Value}}</code>{{end}}</td>
+<td><code>{{.Default}}</code></td>
+<td>{{.Usage}}</td>
+</tr>
+{{end}}</table>
+</body></html>
+`))
@@ -0,0 +1,324 @@
+// Copyright (C) 2023 Michael J. Fromberger. All Rights Reserved.
+
+package flaxhttp_test
+
+import (
+	"encoding/json"
+	"errors"
+	"expvar"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/creachadair/flax"
+	"github.com/creachadair/flax/flaxhttp"
+)
+
+type config struct {
+	Addr    string        `flag:"addr,default=:8080,Service address"`
+	Level   int           `flag:"level,mutable,default=1,Log level"`
+	Timeout time.Duration `flag:"timeout,mutable,default=5s,Request timeout"`
+	Token   string        `flag:"token,mutable,secret,default=hunter2,Access token"`
+	Cache   bool          `flag:"cache,mutable,negatable,default=true,Enable caching"`
+}
+
+func (c *config) Validate() error {
+	if c.Level > 10 && c.Timeout < time.Second {
+		return errors.New("high log levels need a timeout of at least 1s")
+	}
+	return nil
+}
+
+func newServer(t *testing.T) (*config, *flaxhttp.Handler, *httptest.Server) {
+	t.Helper()
+	var cfg config
+	fields, err := flax.Check(&cfg)
+	if err != nil {
+		t.Fatalf("Check failed: %v", err)
+	}
+	fields.Reset()
+	h := flaxhttp.NewHandler(fields)
+	srv := httptest.NewServer(h)
+	t.Cleanup(srv.Close)
+	return &cfg, h, srv
+}
+
+type flagInfo struct {
+	Name, Value, Default string
+	Mutable, Secret      bool
+}
+
+func getJSON(t *testing.T, url string) map[string]flagInfo {
+	t.Helper()
+	rsp, err := http.Get(url + "?format=json")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	defer rsp.Body.Close()
+	if rsp.StatusCode != http.StatusOK {
+		t.Fatalf("Get: status %s", rsp.Status)
+	}
+	var infos []flagInfo
+	if err := json.NewDecoder(rsp.Body).Decode(&infos); err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	out := make(map[string]flagInfo)
+	for _, fi := range infos {
+		out[fi.Name] = fi
+	}
+	return out
+}
+
+func post(t *testing.T, url string, form url.Values) (int, string) {
+	t.Helper()
+	req, err := http.NewRequest("POST", url, strings.NewReader(form.Encode()))
+	if err != nil {
+		t.Fatalf("NewRequest: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set(flaxhttp.RequestHeader, "1")
+	req.SetBasicAuth("alice", "secret")
+	rsp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Post: %v", err)
+	}
+	defer rsp.Body.Close()
+	body, _ := io.ReadAll(rsp.Body)
+	return rsp.StatusCode, string(body)
+}
+
+func TestGet(t *testing.T) {
+	_, _, srv := newServer(t)
+	vals := getJSON(t, srv.URL)
+	if got := vals["addr"]; got.Value != ":8080" || got.Mutable {
+		t.Errorf("addr: got %+v, want immutable :8080", got)
+	}
+	if got := vals["level"]; got.Value != "1" || !got.Mutable {
+		t.Errorf("level: got %+v, want mutable 1", got)
+	}
+	if got := vals["token"]; got.Value != flaxhttp.Redacted || got.Default != flaxhttp.Redacted || !got.Secret {
+		t.Errorf("token: got %+v, want redacted", got)
+	}
+
+	rsp, err := http.Get(srv.URL)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	defer rsp.Body.Close()
+	page, _ := io.ReadAll(rsp.Body)
+	if ct := rsp.Header.Get("Content-Type"); !strings.HasPrefix(ct, "text/html") {
+		t.Errorf("Content-Type: got %q, want text/html", ct)
+	}
+	if !strings.Contains(string(page), "-level") || strings.Contains(string(page), "hunter2") {
+		t.Errorf("Page does not list flags with secrets redacted:\n%s", page)
+	}
+}
+
+func TestPost(t *testing.T) {
+	cfg, h, srv := newServer(t)
+	var seen []flaxhttp.Change
+	h.OnChange = func(c flaxhttp.Change) { seen = append(seen, c) }
+
+	code, body := post(t, srv.URL, url.Values{"level": {"3"}, "token": {"swordfish"}})
+	if code != http.StatusOK {
+		t.Fatalf("Post: got %d %s, want OK", code, body)
+	}
+	if cfg.Level != 3 || cfg.Token != "swordfish" {
+		t.Errorf("Config: got %+v, want level 3 and new token", *cfg)
+	}
+	if strings.Contains(body, "swordfish") {
+		t.Errorf("Response leaks secret: %s", body)
+	}
+
+	audit := h.Audit()
+	if len(audit) != 2 || len(seen) != 2 {
+		t.Fatalf("Audit: got %d entries (%d callbacks), want 2", len(audit), len(seen))
+	}
+	if a := audit[0]; a.User != "alice" || a.Flag != "level" || a.Old != "1" || a.New != "3" {
+		t.Errorf("Audit[0]: got %+v", a)
+	}
+	if a := audit[1]; a.Flag != "token" || a.Old != flaxhttp.Redacted || a.New != flaxhttp.Redacted {
+		t.Errorf("Audit[1]: got %+v", a)
+	}
+
+	for _, tc := range []struct {
+		form url.Values
+		code int
+	}{
+		{url.Values{}, http.StatusBadRequest},
+		{url.Values{"addr": {":1"}}, http.StatusForbidden},
+		{url.Values{"bogus": {"1"}}, http.StatusNotFound},
+		{url.Values{"no-cache": {"true"}}, http.StatusBadRequest},
+		{url.Values{"level": {"1", "2"}}, http.StatusBadRequest},
+		{url.Values{"level": {"5"}, "timeout": {"soon"}}, http.StatusBadRequest},
+		{url.Values{"level": {"20"}, "timeout": {"1ms"}}, http.StatusBadRequest},
+	} {
+		if code, body := post(t, srv.URL, tc.form); code != tc.code {
+			t.Errorf("Post %v: got %d %s, want %d", tc.form, code, body, tc.code)
+		}
+	}
+
+	// The failed updates should not have changed anything.
+	if cfg.Level != 3 || cfg.Timeout != 5*time.Second || cfg.Addr != ":8080" || !cfg.Cache {
+		t.Errorf("Config: got %+v, want unchanged", *cfg)
+	}
+	if n := len(h.Audit()); n != 2 {
+		t.Errorf("Audit: got %d entries, want 2", n)
+	}
+}
+
+// A listFlag is a flag.Value that appends comma-separated values to a list.
+type listFlag []string
+
+func (f *listFlag) Set(s string) error { *f = append(*f, strings.Split(s, ",")...); return nil }
+
+func (f listFlag) String() string { return strings.Join(f, ",") }
+
+type listConfig struct {
+	Tags  listFlag `flag:"tag,mutable,Tags to apply"`
+	Limit int      `flag:"limit,mutable,default=1,Limit on tags"`
+}
+
+func (c *listConfig) Validate() error {
+	if len(c.Tags) > c.Limit {
+		return errors.New("too many tags")
+	}
+	return nil
+}
+
+func TestRollback(t *testing.T) {
+	var cfg listConfig
+	fields, err := flax.Check(&cfg)
+	if err != nil {
+		t.Fatalf("Check failed: %v", err)
+	}
+	srv := httptest.NewServer(flaxhttp.NewHandler(fields))
+	t.Cleanup(srv.Close)
+
+	if code, body := post(t, srv.URL, url.Values{"tag": {"a,b"}, "limit": {"2"}}); code != http.StatusOK {
+		t.Fatalf("Post: got %d %s, want OK", code, body)
+	}
+	for _, form := range []url.Values{
+		{"tag": {"c"}},                     // fails validation
+		{"tag": {"c"}, "limit": {"three"}}, // fails to set
+	} {
+		if code, body := post(t, srv.URL, form); code != http.StatusBadRequest {
+			t.Errorf("Post %v: got %d %s, want %d", form, code, body, http.StatusBadRequest)
+		}
+		if got := cfg.Tags.String(); got != "a,b" || cfg.Limit != 2 {
+			t.Errorf("Post %v: got tags %q limit %d, want a,b and 2", form, got, cfg.Limit)
+		}
+	}
+}
+
+func TestDo(t *testing.T) {
+	cfg, h, srv := newServer(t)
+	stop := make(chan struct{})
+	errc := make(chan error, 1)
+	go func() {
+		defer close(errc)
+		for {
+			var level int
+			var timeout time.Duration
+			h.Do(func() { level, timeout = cfg.Level, cfg.Timeout })
+			if level > 1 && timeout != 2*time.Second {
+				errc <- fmt.Errorf("got level %d with timeout %v, want 2s", level, timeout)
+				return
+			}
+			select {
+			case <-stop:
+				return
+			default:
+			}
+		}
+	}()
+	for i := range 10 {
+		post(t, srv.URL, url.Values{"level": {strconv.Itoa(i)}, "timeout": {"2s"}})
+	}
+	close(stop)
+	if err := <-errc; err != nil {
+		t.Errorf("Do: %v", err)
+	}
+}
+
+func TestCSRF(t *testing.T) {
+	cfg, _, srv := newServer(t)
+	postForm := func(form url.Values) int {
+		t.Helper()
+		rsp, err := http.PostForm(srv.URL, form)
+		if err != nil {
+			t.Fatalf("Post: %v", err)
+		}
+		rsp.Body.Close()
+		return rsp.StatusCode
+	}
+
+	// A plain form submission without the header or a token is rejected.
+	if code := postForm(url.Values{"level": {"2"}}); code != http.StatusForbidden {
+		t.Errorf("Post without token: got %d, want %d", code, http.StatusForbidden)
+	}
+	if code := postForm(url.Values{"level": {"2"}, "_token": {"bogus"}}); code != http.StatusForbidden {
+		t.Errorf("Post with bad token: got %d, want %d", code, http.StatusForbidden)
+	}
+	if cfg.Level != 1 {
+		t.Errorf("Level: got %d, want 1", cfg.Level)
+	}
+
+	// A submission with the token from the page is accepted.
+	rsp, err := http.Get(srv.URL)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	page, _ := io.ReadAll(rsp.Body)
+	rsp.Body.Close()
+	m := regexp.MustCompile(`name="_token" value="([^"]+)"`).FindSubmatch(page)
+	if m == nil {
+		t.Fatalf("Page does not contain a token:\n%s", page)
+	}
+	if code := postForm(url.Values{"level": {"2"}, "_token": {string(m[1])}}); code != http.StatusOK {
+		t.Errorf("Post with token: got %d, want %d", code, http.StatusOK)
+	}
+	if cfg.Level != 2 {
+		t.Errorf("Level: got %d, want 2", cfg.Level)
+	}
+}
+
+func TestMethod(t *testing.T) {
+	_, _, srv := newServer(t)
+	req, _ := http.NewRequest("DELETE", srv.URL, nil)
+	rsp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	rsp.Body.Close()
+	if rsp.StatusCode != http.StatusMethodNotAllowed {
+		t.Errorf("Delete: got %s, want 405", rsp.Status)
+	}
+}
+
+func TestPublish(t *testing.T) {
+	_, h, srv := newServer(t)
+	flaxhttp.Publish("test-flags", h)
+	if code, body := post(t, srv.URL, url.Values{"level": {"2"}}); code != http.StatusOK {
+		t.Fatalf("Post: got %d %s, want OK", code, body)
+	}
+
+	v := expvar.Get("test-flags")
+	if v == nil {
+		t.Fatal("Variable test-flags not published")
+	}
+	var got map[string]any
+	if err := json.Unmarshal([]byte(v.String()), &got); err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	if got["level"] != 2.0 || got["addr"] != ":8080" || got["token"] != flaxhttp.Redacted {
+		t.Errorf("Published values: got %v", got)
+	}
+}
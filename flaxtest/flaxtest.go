@@ -0,0 +1,152 @@
+// Copyright (C) 2023 Michael J. Fromberger. All Rights Reserved.
+
+// Package flaxtest provides support code for testing programs that use the
+// [flax] package to parse flags.
+//
+// The [Parse] function constructs a fresh value of a flag struct type, binds
+// and parses it against an isolated [flag.FlagSet], and returns the populated
+// value:
+//
+//	v, err := flaxtest.Parse[myFlags](t, flaxtest.Env{"TOOL_LEVEL": "3"}, []string{"-v"},
+//		flax.EnvPrefix("TOOL"))
+//
+// The [CheckUsage] function compares the usage text for a flag struct type to
+// a golden file. Set the environment variable FLAXTEST_UPDATE=1 to rewrite
+// golden files with the current output instead.
+package flaxtest
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/creachadair/flax"
+)
+
+// Env is a collection of environment variables to set for the duration of a
+// test. A variable set to "" is treated as unset by [flax].
+type Env map[string]string
+
+// An Error reports a failure to check or parse a flag struct.
+type Error struct {
+	Stage  string // the stage that failed: "check" or "parse"
+	Output string // any output written by the flag set
+	Err    error  // the underlying error
+}
+
+// Error implements the error interface.
+func (e *Error) Error() string { return fmt.Sprintf("%s: %v", e.Stage, e.Err) }
+
+// Unwrap supports error wrapping.
+func (e *Error) Unwrap() error { return e.Err }
+
+// Parse constructs a new value of type T, which must be a struct type, and
+// checks and binds its flags to a new flag set. It then parses args with
+// [flax.Fields.Parse] and returns the resulting value, or an error of
+// concrete type [*Error]. The usage message of the flag set, recorded in the
+// Output of the error, lists the flags as [flax.Fields.PrintDefaults] does.
+//
+// Before checking the value, Parse sets the specified environment variables
+// for the duration of the test. Because it modifies the environment of the
+// process, Parse may not be used in parallel tests.
+func Parse[T any](t testing.TB, env Env, args []string, opts ...flax.ParseOption) (*T, error) {
+	t.Helper()
+	for key, val := range env {
+		t.Setenv(key, val)
+	}
+	v := new(T)
+	fields, err := flax.Check(v)
+	if err != nil {
+		return nil, &Error{Stage: "check", Err: err}
+	}
+	var out bytes.Buffer
+	fs := flag.NewFlagSet(t.Name(), flag.ContinueOnError)
+	fs.SetOutput(&out)
+	fields.Bind(fs)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage of %s:\n", fs.Name())
+		fields.PrintDefaults(fs)
+	}
+	if err := fields.Parse(fs, args, opts...); err != nil {
+		return nil, &Error{Stage: "parse", Output: out.String(), Err: err}
+	}
+	return v, nil
+}
+
+// MustParse is as [Parse], but fails the test if parsing reports an error.
+func MustParse[T any](t testing.TB, env Env, args []string, opts ...flax.ParseOption) *T {
+	t.Helper()
+	v, err := Parse[T](t, env, args, opts...)
+	if err != nil {
+		t.Fatalf("Parse %T: %v", v, err)
+	}
+	return v
+}
+
+// CommandLine replaces [flag.CommandLine] with a new empty flag set for the
+// duration of the test, and returns the new flag set. The original flag set
+// is restored when the test ends. This allows code under test that binds
+// flags to the global flag set to be called repeatedly.
+func CommandLine(t testing.TB) *flag.FlagSet {
+	t.Helper()
+	save := flag.CommandLine
+	t.Cleanup(func() { flag.CommandLine = save })
+	flag.CommandLine = flag.NewFlagSet(save.Name(), flag.ContinueOnError)
+	return flag.CommandLine
+}
+
+// Usage returns the usage text for the flags of a new value of type T, as
+// rendered by [flax.Fields.PrintDefaults]. It fails the test if T does not
+// have any valid flags.
+func Usage[T any](t testing.TB) string {
+	t.Helper()
+	fields, err := flax.Check(new(T))
+	if err != nil {
+		t.Fatalf("Check %T: %v", new(T), err)
+	}
+	var out bytes.Buffer
+	fs := flag.NewFlagSet(t.Name(), flag.ContinueOnError)
+	fs.SetOutput(&out)
+	fields.Bind(fs)
+	fields.PrintDefaults(fs)
+	return out.String()
+}
+
+// CheckUsage compares the usage text for the flags of a new value of type T
+// (see [Usage]) to the contents of the golden file at path, and fails the test
+// if they differ. If the environment variable FLAXTEST_UPDATE is set to a
+// non-empty value, CheckUsage instead writes the current usage text to path.
+func CheckUsage[T any](t testing.TB, path string) {
+	t.Helper()
+	CheckGolden(t, Usage[T](t), path)
+}
+
+// CheckGolden compares got to the contents of the golden file at path, and
+// fails the test if they differ. If the environment variable FLAXTEST_UPDATE
+// is set to a non-empty value, CheckGolden instead writes got to path.
+func CheckGolden(t testing.TB, got, path string) {
+	t.Helper()
+	if os.Getenv("FLAXTEST_UPDATE") != "" {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Create golden directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(got), 0644); err != nil {
+			t.Fatalf("Update golden file: %v", err)
+		}
+		t.Logf("Updated golden file %q", path)
+		return
+	}
+	want, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("Golden file %q not found (set FLAXTEST_UPDATE=1 to create it)", path)
+	} else if err != nil {
+		t.Fatalf("Read golden file: %v", err)
+	}
+	if got != string(want) {
+		t.Errorf("Output does not match golden file %q:\ngot:\n%s\nwant:\n%s", path, got, want)
+	}
+}
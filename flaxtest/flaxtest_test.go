@@ -0,0 +1,98 @@
+// Copyright (C) 2023 Michael J. Fromberger. All Rights Reserved.
+
+package flaxtest_test
+
+import (
+	"errors"
+	"flag"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/creachadair/flax"
+	"github.com/creachadair/flax/flaxtest"
+)
+
+type testFlags struct {
+	Verbose bool          `flag:"v,Enable verbose logging"`
+	Level   int           `flag:"level,default=$TEST_LEVEL,Logging level"`
+	Name    string        `flag:"name,default=anon,User name"`
+	Wait    time.Duration `flag:"wait,default=5s,Time to wait"`
+}
+
+func TestParse(t *testing.T) {
+	v := flaxtest.MustParse[testFlags](t, flaxtest.Env{"TEST_LEVEL": "3"}, []string{"-v", "-wait", "1m"})
+	want := testFlags{Verbose: true, Level: 3, Name: "anon", Wait: time.Minute}
+	if *v != want {
+		t.Errorf("Parse: got %+v, want %+v", *v, want)
+	}
+}
+
+func TestParseEnvPrefix(t *testing.T) {
+	env := flaxtest.Env{"TEST_LEVEL": "3", "TOOL_NAME": "alice"}
+	v := flaxtest.MustParse[testFlags](t, env, nil, flax.EnvPrefix("TOOL"))
+	want := testFlags{Level: 3, Name: "alice", Wait: 5 * time.Second}
+	if *v != want {
+		t.Errorf("Parse: got %+v, want %+v", *v, want)
+	}
+}
+
+func TestParseError(t *testing.T) {
+	t.Run("Check", func(t *testing.T) {
+		_, err := flaxtest.Parse[testFlags](t, flaxtest.Env{"TEST_LEVEL": "high"}, nil)
+		var fe *flaxtest.Error
+		if !errors.As(err, &fe) {
+			t.Fatalf("Parse: got %v, want *flaxtest.Error", err)
+		} else if fe.Stage != "check" {
+			t.Errorf("Stage: got %q, want check", fe.Stage)
+		}
+	})
+	t.Run("Parse", func(t *testing.T) {
+		_, err := flaxtest.Parse[testFlags](t, nil, []string{"-bogus"})
+		var fe *flaxtest.Error
+		if !errors.As(err, &fe) {
+			t.Fatalf("Parse: got %v, want *flaxtest.Error", err)
+		}
+		if fe.Stage != "parse" {
+			t.Errorf("Stage: got %q, want parse", fe.Stage)
+		}
+		if fe.Output == "" {
+			t.Error("Output: got empty, want usage output")
+		}
+	})
+	t.Run("Help", func(t *testing.T) {
+		type negFlags struct {
+			Cache bool `flag:"cache,negatable,default=true,Use the cache"`
+		}
+		_, err := flaxtest.Parse[negFlags](t, nil, []string{"-h"})
+		var fe *flaxtest.Error
+		if !errors.As(err, &fe) {
+			t.Fatalf("Parse: got %v, want *flaxtest.Error", err)
+		}
+		if !strings.Contains(fe.Output, "-cache, -no-cache\n") {
+			t.Errorf("Output: negatable flags are not listed together:\n%s", fe.Output)
+		}
+	})
+}
+
+func TestCommandLine(t *testing.T) {
+	orig := flag.CommandLine
+	t.Run("Isolated", func(t *testing.T) {
+		for i := 0; i < 2; i++ {
+			fs := flaxtest.CommandLine(t)
+			if fs == orig || flag.CommandLine != fs {
+				t.Fatal("CommandLine did not replace the global flag set")
+			}
+			// Binding the same flags twice to the global set would panic.
+			flax.MustBind(flag.CommandLine, new(testFlags))
+		}
+	})
+	if flag.CommandLine != orig {
+		t.Error("CommandLine did not restore the global flag set")
+	}
+}
+
+func TestCheckUsage(t *testing.T) {
+	t.Setenv("TEST_LEVEL", "")
+	flaxtest.CheckUsage[testFlags](t, "testdata/usage.golden")
+}
@@ -0,0 +1,154 @@
+// Copyright (C) 2023 Michael J. Fromberger. All Rights Reserved.
+
+package flax
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+)
+
+// A flagGroup is a set of fields related by the xor or oneof option.
+type flagGroup struct {
+	kind   string // "xor" or "oneof"
+	name   string // the group name from the tag
+	fields []*Field
+}
+
+// groups returns the xor and oneof groups of f, in order of first
+// occurrence.
+func (f Fields) groups() []*flagGroup {
+	var out []*flagGroup
+	for _, fi := range f {
+		for _, kind := range []string{"xor", "oneof"} {
+			name, ok := fi.opts[kind]
+			if !ok {
+				continue
+			}
+			i := slices.IndexFunc(out, func(g *flagGroup) bool { return g.kind == kind && g.name == name })
+			if i < 0 {
+				i = len(out)
+				out = append(out, &flagGroup{kind: kind, name: name})
+			}
+			out[i].fields = append(out[i].fields, fi)
+		}
+	}
+	return out
+}
+
+// requires returns the flag names required by fi.
+func (fi *Field) requires() []string { return strings.Fields(fi.opts["requires"]) }
+
+// checkRelations reports the fields of f whose xor, oneof, or requires
+// options are not valid, and resolves the flags named by each requires option
+// to the fields of f, so that they follow any later renaming of the fields.
+// The names are the flag names of the struct.
+func (f Fields) checkRelations(names map[string]bool) FieldErrors {
+	byName := make(map[string]*Field)
+	for _, fi := range f {
+		byName[fi.Name] = fi
+	}
+	var errs FieldErrors
+	for _, fi := range f {
+		fail := func(format string, args ...any) {
+			errs = append(errs, &FieldError{
+				Field: fi.field.Name,
+				Flag:  fi.Name,
+				Tag:   string(fi.field.Tag),
+				Kind:  KindOption,
+				Err:   fmt.Errorf(format, args...),
+			})
+		}
+		for _, kind := range []string{"xor", "oneof"} {
+			if name, ok := fi.opts[kind]; ok && name == "" {
+				fail("empty %s group name", kind)
+			}
+		}
+		if _, ok := fi.opts["requires"]; ok && len(fi.requires()) == 0 {
+			fail("empty requires option")
+		}
+		for _, name := range fi.requires() {
+			if name == fi.Name {
+				fail("flag requires itself")
+			} else if !names[name] {
+				fail("requires unknown flag %q", name)
+			} else if req := byName[name]; req != nil {
+				fi.reqs = append(fi.reqs, req)
+			}
+		}
+	}
+	return errs
+}
+
+// CheckGroups reports an error if the flags of f, as set in the flag set to
+// which they were most recently bound, violate the relations declared by the
+// xor, oneof, and requires tag options. The error describes every violation,
+// naming the flags involved. [Fields.Parse] calls CheckGroups after parsing.
+func (f Fields) CheckGroups() error {
+	var errs []error
+	for _, g := range f.groups() {
+		var set []string
+		for _, fi := range g.fields {
+			if fi.IsSet() {
+				set = append(set, "-"+fi.Name)
+			}
+		}
+		switch {
+		case g.kind == "xor" && len(set) > 1:
+			errs = append(errs, fmt.Errorf("at most one of %s may be set; got %s",
+				g.flagList(), strings.Join(set, ", ")))
+		case g.kind == "oneof" && len(set) == 0:
+			errs = append(errs, fmt.Errorf("at least one of %s must be set", g.flagList()))
+		}
+	}
+	for _, fi := range f {
+		if !fi.IsSet() {
+			continue
+		}
+		for _, req := range fi.reqs {
+			if !req.IsSet() {
+				errs = append(errs, fmt.Errorf("flag -%s requires -%s", fi.Name, req.Name))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// flagList renders the names of the flags in g as a comma-separated list.
+func (g *flagGroup) flagList() string { return flagList(g.fields) }
+
+// flagList renders the names of the flags of fields as a comma-separated list.
+func flagList(fields []*Field) string {
+	names := make([]string, len(fields))
+	for i, fi := range fields {
+		names[i] = "-" + fi.Name
+	}
+	return strings.Join(names, ", ")
+}
+
+// printRelations writes a description of the xor, oneof, and requires
+// relations among the fields of f to w, if there are any.
+func (f Fields) printRelations(w io.Writer) {
+	var lines []string
+	for _, g := range f.groups() {
+		if g.kind == "xor" {
+			lines = append(lines, "at most one of: "+g.flagList())
+		} else {
+			lines = append(lines, "at least one of: "+g.flagList())
+		}
+	}
+	for _, fi := range f {
+		if len(fi.reqs) != 0 {
+			lines = append(lines, fmt.Sprintf("-%s requires: %s", fi.Name, flagList(fi.reqs)))
+		}
+	}
+	if len(lines) == 0 {
+		return
+	}
+	fmt.Fprintln(w, "\nFlag constraints:")
+	for _, line := range lines {
+		fmt.Fprintln(w, "  "+line)
+	}
+}
@@ -0,0 +1,190 @@
+// Copyright (C) 2023 Michael J. Fromberger. All Rights Reserved.
+
+package flax_test
+
+import (
+	"errors"
+	"flag"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/creachadair/flax"
+)
+
+type groupFlags struct {
+	JSON  bool   `flag:"json,xor=format,JSON output"`
+	CSV   bool   `flag:"csv,xor=format,CSV output"`
+	YAML  bool   `flag:"yaml,xor=format,YAML output"`
+	Input string `flag:"input,oneof=source,Input file"`
+	URL   string `flag:"url,oneof=source,env=TEST_GROUP_URL,Input URL"`
+	Key   string `flag:"tls-key,requires=tls-cert,TLS key"`
+	Cert  string `flag:"tls-cert,TLS certificate"`
+	Both  bool   `flag:"both,requires='tls-key tls-cert',Both"`
+}
+
+func TestGroups(t *testing.T) {
+	tests := []struct {
+		args []string
+		env  string
+		want []string // error substrings; nil for success
+	}{
+		{[]string{"-input", "x"}, "", nil},
+		{nil, "http://x", nil},
+		{[]string{"-json", "-url", "u"}, "", nil},
+		{[]string{"-input", "x", "-tls-key", "k", "-tls-cert", "c"}, "", nil},
+		{[]string{"-input", "x", "-tls-cert", "c"}, "", nil},
+
+		{nil, "", []string{"at least one of -input, -url must be set"}},
+		{[]string{"-input", "x", "-json", "-yaml"}, "", []string{
+			"at most one of -json, -csv, -yaml may be set; got -json, -yaml",
+		}},
+		{[]string{"-input", "x", "-tls-key", "k"}, "", []string{"flag -tls-key requires -tls-cert"}},
+		{[]string{"-both", "-json", "-csv"}, "", []string{
+			"at most one of -json, -csv, -yaml may be set; got -json, -csv",
+			"at least one of -input, -url must be set",
+			"flag -both requires -tls-key",
+			"flag -both requires -tls-cert",
+		}},
+	}
+	for _, tc := range tests {
+		t.Setenv("TEST_GROUP_URL", tc.env)
+		var flags groupFlags
+		fi := flax.MustCheck(&flags)
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		fs.SetOutput(io.Discard)
+		fi.Bind(fs)
+		err := fi.Parse(fs, tc.args)
+		if tc.want == nil {
+			if err != nil {
+				t.Errorf("Parse %q: unexpected error: %v", tc.args, err)
+			}
+			continue
+		}
+		if err == nil {
+			t.Errorf("Parse %q: got nil, want error", tc.args)
+			continue
+		}
+		for _, want := range tc.want {
+			if !strings.Contains(err.Error(), want) {
+				t.Errorf("Parse %q: got error %v, want %q", tc.args, err, want)
+			}
+		}
+		if got, want := strings.Count(err.Error(), "\n")+1, len(tc.want); got != want {
+			t.Errorf("Parse %q: got %d errors, want %d", tc.args, got, want)
+		}
+	}
+}
+
+func TestGroupsUsage(t *testing.T) {
+	var flags groupFlags
+	fi := flax.MustCheck(&flags)
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fi.Bind(fs)
+
+	var out strings.Builder
+	fs.SetOutput(&out)
+	fi.PrintDefaults(fs)
+	const want = `
+Flag constraints:
+  at most one of: -json, -csv, -yaml
+  at least one of: -input, -url
+  -tls-key requires: -tls-cert
+  -both requires: -tls-key, -tls-cert
+`
+	if got := out.String(); !strings.HasSuffix(got, want) {
+		t.Errorf("PrintDefaults:\ngot:\n%s\nwant suffix:\n%s", got, want)
+	}
+}
+
+func TestGroupsHelp(t *testing.T) {
+	const want = "\nFlag constraints:\n  at most one of: -json, -csv, -yaml\n"
+
+	t.Run("Parse", func(t *testing.T) {
+		var out strings.Builder
+		_, _, err := flax.Parse[groupFlags]([]string{"-h"}, flax.ParseOutput(&out))
+		if !errors.Is(err, flag.ErrHelp) {
+			t.Fatalf("Parse -h: got %v, want %v", err, flag.ErrHelp)
+		}
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("Help output: missing %q:\n%s", want, out.String())
+		}
+	})
+	t.Run("MustBindAll", func(t *testing.T) {
+		var out strings.Builder
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		fs.SetOutput(&out)
+		flax.MustBindAll(fs, new(negFlags), new(groupFlags))
+		if err := fs.Parse([]string{"-help"}); !errors.Is(err, flag.ErrHelp) {
+			t.Fatalf("Parse -help: got %v, want %v", err, flag.ErrHelp)
+		}
+		got := out.String()
+		if !strings.Contains(got, want) {
+			t.Errorf("Help output: missing %q:\n%s", want, got)
+		}
+		if !strings.Contains(got, "-cache, -no-cache\n") {
+			t.Errorf("Help output: negatable flags are not listed together:\n%s", got)
+		}
+	})
+}
+
+func TestGroupsPrefix(t *testing.T) {
+	type tlsFlags struct {
+		Key  string `flag:"tls-key,requires=tls-cert,TLS key"`
+		Cert string `flag:"tls-cert,TLS certificate"`
+	}
+	var a, b tlsFlags
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	fa := flax.MustCheck(&a).AddPrefix("a-")
+	fb := flax.MustCheck(&b).AddPrefix("b-")
+	fa.Bind(fs)
+	fb.Bind(fs)
+
+	if err := fa.Parse(fs, []string{"-a-tls-key", "k", "-a-tls-cert", "c"}); err != nil {
+		t.Errorf("Parse: unexpected error: %v", err)
+	}
+	if err := fb.CheckGroups(); err != nil {
+		t.Errorf("CheckGroups: unexpected error: %v", err)
+	}
+
+	fs = flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	fa.Bind(fs)
+	fb.Bind(fs)
+	err := fa.Parse(fs, []string{"-a-tls-key", "k", "-b-tls-cert", "c"})
+	if want := "flag -a-tls-key requires -a-tls-cert"; err == nil || err.Error() != want {
+		t.Errorf("Parse: got %v, want %q", err, want)
+	}
+
+	var out strings.Builder
+	fs.SetOutput(&out)
+	fa.PrintDefaults(fs)
+	if want := "-a-tls-key requires: -a-tls-cert"; !strings.Contains(out.String(), want) {
+		t.Errorf("PrintDefaults: got:\n%s\nwant %q", out.String(), want)
+	}
+}
+
+func TestGroupErrors(t *testing.T) {
+	for _, tc := range []struct {
+		v    any
+		want string
+	}{
+		{&struct {
+			A bool `flag:"a,requires=b,A"`
+		}{}, `requires unknown flag "b"`},
+		{&struct {
+			A bool `flag:"a,requires=a,A"`
+		}{}, "flag requires itself"},
+		{&struct {
+			A bool `flag:"a,xor=,A"`
+		}{}, "empty xor group name"},
+		{&struct {
+			A bool `flag:"a,requires=' ',A"`
+		}{}, "empty requires option"},
+	} {
+		if _, err := flax.Check(tc.v); err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("Check %T: got %v, want %q", tc.v, err, tc.want)
+		}
+	}
+}
@@ -0,0 +1,425 @@
+// Copyright (C) 2023 Michael J. Fromberger. All Rights Reserved.
+
+// Package tagcheck implements static checking of flax struct tags in Go
+// source code, for use by the flaxgen and flaxlint tools.
+package tagcheck
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"log/slog"
+	"maps"
+	"net"
+	"net/netip"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/creachadair/flax"
+)
+
+// A Package describes the struct types with flag tags in a package.
+type Package struct {
+	Name    string    // the package name
+	Structs []*Struct // struct types with at least one valid flag field
+}
+
+// A Struct describes a struct type with flag tags.
+type Struct struct {
+	Name   string
+	Pos    token.Position
+	Fields []*Field // valid flag fields, in declaration order
+}
+
+// A Field describes a struct field with a valid flag tag.
+type Field struct {
+	Spec flax.FieldSpec
+	Pos  token.Position
+
+	// Default is the default value of the field, if it has one of the types
+	// bound directly by the flag package (bool, float64, int, int64, string,
+	// uint, uint64, or time.Duration) and its default can be resolved
+	// statically. Otherwise Default is nil.
+	Default any
+}
+
+// A Diagnostic reports a problem with the flag tag of a struct field.
+type Diagnostic struct {
+	Pos     token.Position
+	Struct  string // the name of the struct type
+	Field   string // the name of the field, if known
+	Message string
+
+	// Warning reports whether the problem may not be an error, because it
+	// depends on types registered at run time.
+	Warning bool
+}
+
+// Error implements the error interface.
+func (d *Diagnostic) Error() string {
+	msg := d.Message
+	if d.Warning {
+		msg = "warning: " + msg
+	}
+	if d.Field == "" {
+		return fmt.Sprintf("%s: %s: %s", d.Pos, d.Struct, msg)
+	}
+	return fmt.Sprintf("%s: %s: field %q: %s", d.Pos, d.Struct, d.Field, msg)
+}
+
+// A Config controls how [Load] reads and checks a package. A nil *Config is
+// ready for use and has default values.
+type Config struct {
+	// Skip, if non-nil, reports whether to ignore the source file with the
+	// given base name.
+	Skip func(name string) bool
+
+	// Registered lists the names of types that the program registers with
+	// flax at run time, for example with [flax.RegisterType]. Fields of these
+	// types are accepted, but their defaults are not checked. A type declared
+	// in the package being checked is named without qualification, as "Color"
+	// or "*Color"; other types are qualified by their import path, as
+	// "example.com/color.Color".
+	Registered []string
+}
+
+func (c *Config) skip(name string) bool { return c != nil && c.Skip != nil && c.Skip(name) }
+
+func (c *Config) registered() []string {
+	if c == nil {
+		return nil
+	}
+	return c.Registered
+}
+
+// Load parses and type-checks the non-test Go source files in dir, other than
+// those skipped by cfg, and checks the flag tags of the struct types declared
+// at the top level of the package.
+//
+// Type errors in the package are ignored, since the package may refer to
+// declarations that have not yet been generated. Fields whose types cannot be
+// resolved are not type checked. A field of a named type that is not flag
+// compatible, and is not listed in cfg.Registered, is reported with a warning,
+// since the type may be registered at run time.
+func Load(dir string, cfg *Config) (*Package, []*Diagnostic, error) {
+	fset := token.NewFileSet()
+	name, files, err := parseDir(fset, dir, cfg.skip)
+	if err != nil {
+		return nil, nil, err
+	}
+	conf := types.Config{
+		Importer: importer.ForCompiler(fset, "source", nil),
+		Error:    func(error) {},
+	}
+	info := &types.Info{Defs: make(map[*ast.Ident]types.Object)}
+	tpkg, _ := conf.Check(name, fset, files, info)
+	registered := make(map[string]bool)
+	for _, name := range cfg.registered() {
+		registered[name] = true
+	}
+	isRegistered := func(t types.Type) bool {
+		return registered[types.TypeString(types.Unalias(t), types.RelativeTo(tpkg))]
+	}
+
+	pkg := &Package{Name: name}
+	var diags []*Diagnostic
+	for _, file := range files {
+		for _, decl := range file.Decls {
+			gd, ok := decl.(*ast.GenDecl)
+			if !ok || gd.Tok != token.TYPE {
+				continue
+			}
+			for _, spec := range gd.Specs {
+				ts := spec.(*ast.TypeSpec)
+				st, ok := ts.Type.(*ast.StructType)
+				if !ok || ts.TypeParams != nil {
+					continue
+				}
+				s, ds := checkStruct(fset, info, isRegistered, ts.Name.Name, st)
+				diags = append(diags, ds...)
+				if len(s.Fields) != 0 {
+					s.Pos = fset.Position(ts.Pos())
+					pkg.Structs = append(pkg.Structs, s)
+				}
+			}
+		}
+	}
+	return pkg, diags, nil
+}
+
+// parseDir parses the non-test Go source files in dir not excluded by skip.
+func parseDir(fset *token.FileSet, dir string, skip func(string) bool) (string, []*ast.File, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.go"))
+	if err != nil {
+		return "", nil, err
+	}
+	var pkgName string
+	var files []*ast.File
+	for _, path := range paths {
+		base := filepath.Base(path)
+		if strings.HasSuffix(base, "_test.go") || skip(base) {
+			continue
+		}
+		f, err := parser.ParseFile(fset, path, nil, parser.ParseComments|parser.SkipObjectResolution)
+		if err != nil {
+			return "", nil, err
+		}
+		if pkgName == "" {
+			pkgName = f.Name.Name
+		} else if f.Name.Name != pkgName {
+			return "", nil, fmt.Errorf("%s: found package %s, want %s", path, f.Name.Name, pkgName)
+		}
+		files = append(files, f)
+	}
+	if len(files) == 0 {
+		return "", nil, fmt.Errorf("no Go source files found in %q", dir)
+	}
+	return pkgName, files, nil
+}
+
+// checkStruct reports the valid flag fields of the struct type st named name,
+// and any problems with the tags of its fields. Fields of types for which
+// registered reports true are not type checked.
+func checkStruct(fset *token.FileSet, info *types.Info, registered func(types.Type) bool, name string, st *ast.StructType) (*Struct, []*Diagnostic) {
+	s := &Struct{Name: name}
+	var diags []*Diagnostic
+	seen := make(map[string]bool)
+	for _, field := range st.Fields.List {
+		if field.Tag == nil {
+			continue
+		}
+		text, err := strconv.Unquote(field.Tag.Value)
+		if err != nil {
+			continue // should not happen; the file parsed
+		}
+		spec, ok, err := flax.ParseTag(reflect.StructTag(text))
+		if !ok {
+			continue
+		}
+		d := &Diagnostic{Pos: fset.Position(field.Pos()), Struct: name}
+		if len(field.Names) == 0 {
+			d.Message = "embedded fields are not supported"
+			diags = append(diags, d)
+			continue
+		} else if len(field.Names) > 1 {
+			d.Message = "multiple fields share a flag tag"
+			diags = append(diags, d)
+			continue
+		}
+		id := field.Names[0]
+		if !id.IsExported() {
+			continue // unexported fields are not considered
+		}
+		d.Field = id.Name
+		if err != nil {
+			d.Message = err.Error()
+			diags = append(diags, d)
+			continue
+		}
+		if seen[spec.Name] {
+			d.Message = fmt.Sprintf("duplicate flag name %q", spec.Name)
+			diags = append(diags, d)
+		} else if spec.Usage == "" {
+			d.Message = "empty usage string"
+			diags = append(diags, d)
+		}
+		seen[spec.Name] = true
+		spec.Field = id.Name
+
+		var dvalue any
+		if obj := info.Defs[id]; obj != nil && !registered(obj.Type()) {
+			dvalue, err = checkType(obj.Type(), spec)
+			if err != nil {
+				d.Message = err.Error()
+				d.Warning = errors.As(err, new(unregisteredError))
+				diags = append(diags, d)
+				if !d.Warning {
+					continue
+				}
+			}
+		}
+		s.Fields = append(s.Fields, &Field{Spec: spec, Pos: d.Pos, Default: dvalue})
+	}
+	return s, append(diags, checkRelations(s, seen)...)
+}
+
+// checkRelations reports problems with the xor, oneof, and requires options
+// of the fields of s, following the rules of [flax.Check]. The names are the
+// flag names of the struct.
+func checkRelations(s *Struct, names map[string]bool) []*Diagnostic {
+	var diags []*Diagnostic
+	for _, f := range s.Fields {
+		fail := func(format string, args ...any) {
+			diags = append(diags, &Diagnostic{
+				Pos:     f.Pos,
+				Struct:  s.Name,
+				Field:   f.Spec.Field,
+				Message: fmt.Sprintf(format, args...),
+			})
+		}
+		for _, kind := range []string{"xor", "oneof"} {
+			if name, ok := f.Spec.Options[kind]; ok && name == "" {
+				fail("empty %s group name", kind)
+			}
+		}
+		req, ok := f.Spec.Options["requires"]
+		if ok && len(strings.Fields(req)) == 0 {
+			fail("empty requires option")
+		}
+		for _, name := range strings.Fields(req) {
+			if name == f.Spec.Name {
+				fail("flag requires itself")
+			} else if !names[name] {
+				fail("requires unknown flag %q", name)
+			}
+		}
+	}
+	return diags
+}
+
+// checkType reports whether a field of type t is compatible with flax, and
+// whether the default value given by spec is valid for values of type t. If
+// the field has one of the types bound directly by the flag package, and its
+// default does not depend on the run-time environment or field value,
+// checkType also returns the resolved default.
+func checkType(t types.Type, spec flax.FieldSpec) (any, error) {
+	if zero := zeroValue(t); zero != nil {
+		// The default value can be checked by constructing a field. Defaults
+		// read from the environment are not checked, since the environment at
+		// check time is not the environment at run time.
+		d := spec.Options["default"]
+		if strings.HasPrefix(d, "$") && !strings.HasPrefix(d, "$$") {
+			return nil, nil
+		}
+		// Relations with other fields are checked separately.
+		spec.Options = maps.Clone(spec.Options)
+		for _, key := range []string{"xor", "oneof", "requires"} {
+			delete(spec.Options, key)
+		}
+		fi, err := flax.NewField(zero, spec)
+		if err != nil || d == "*" {
+			return nil, err // "*" means the value of the field at run time
+		}
+		switch zero.(type) {
+		case *bool, *float64, *int, *int64, *string, *uint, *uint64, *time.Duration:
+			fs := flag.NewFlagSet(spec.Name, flag.ContinueOnError)
+			fi.Bind(fs)
+			if g, ok := fs.Lookup(spec.Name).Value.(flag.Getter); ok {
+				return g.Get(), nil
+			}
+		}
+		return nil, nil
+	}
+	if hasMethods(t, "Set", "String") {
+		return nil, nil // flag.Value
+	}
+	if hasMethods(t, "MarshalText", "UnmarshalText") {
+		return nil, nil // encoding.TextMarshaler and encoding.TextUnmarshaler
+	}
+	if t.Underlying() == types.Typ[types.Invalid] {
+		return nil, nil // an unresolved type cannot be checked
+	}
+	base := types.Unalias(t)
+	if p, ok := base.(*types.Pointer); ok {
+		base = types.Unalias(p.Elem())
+	}
+	if _, ok := base.(*types.Named); ok {
+		return nil, unregisteredError{t}
+	}
+	return nil, fmt.Errorf("type %s is not flag compatible", t)
+}
+
+// unregisteredError reports a named type that is not flag compatible unless
+// it is registered with flax at run time.
+type unregisteredError struct{ t types.Type }
+
+func (e unregisteredError) Error() string {
+	return fmt.Sprintf("type %s is not flag compatible unless registered at run time", e.t)
+}
+
+// StdTypes are the types other than the basic types that flax supports with
+// built-in parsing, whose default values can therefore be checked statically.
+// It must include the standard library types supported by [flax.Check].
+var StdTypes = []reflect.Type{
+	reflect.TypeFor[time.Duration](),
+	reflect.TypeFor[time.Time](),
+	reflect.TypeFor[*time.Location](),
+	reflect.TypeFor[net.IP](),
+	reflect.TypeFor[netip.Addr](),
+	reflect.TypeFor[netip.AddrPort](),
+	reflect.TypeFor[*url.URL](),
+	reflect.TypeFor[*regexp.Regexp](),
+	reflect.TypeFor[os.FileMode](),
+	reflect.TypeFor[slog.Level](),
+	reflect.TypeFor[flax.Duration](),
+	reflect.TypeFor[flax.Size](),
+}
+
+// stdTypes maps the qualified names of StdTypes to their types.
+var stdTypes = func() map[string]reflect.Type {
+	m := make(map[string]reflect.Type)
+	for _, rt := range StdTypes {
+		m[typeName(rt)] = rt
+	}
+	return m
+}()
+
+// typeName returns the name of rt qualified by its package path, in the format
+// used by [types.TypeString].
+func typeName(rt reflect.Type) string {
+	if rt.Kind() == reflect.Pointer {
+		return "*" + typeName(rt.Elem())
+	}
+	return rt.PkgPath() + "." + rt.Name()
+}
+
+// zeroValue returns a pointer to a zero value of the flag type corresponding
+// to t, or nil if t is not a basic flag type or one of StdTypes.
+func zeroValue(t types.Type) any {
+	if b, ok := t.(*types.Basic); ok {
+		switch b.Kind() {
+		case types.Bool:
+			return new(bool)
+		case types.Float64:
+			return new(float64)
+		case types.Int:
+			return new(int)
+		case types.Int64:
+			return new(int64)
+		case types.String:
+			return new(string)
+		case types.Uint:
+			return new(uint)
+		case types.Uint64:
+			return new(uint64)
+		}
+	}
+	name := types.TypeString(types.Unalias(t), func(p *types.Package) string { return p.Path() })
+	if rt, ok := stdTypes[name]; ok {
+		return reflect.New(rt).Interface()
+	}
+	return nil
+}
+
+// hasMethods reports whether the method set of *t includes all the given
+// method names.
+func hasMethods(t types.Type, names ...string) bool {
+	ms := types.NewMethodSet(types.NewPointer(t))
+	for _, name := range names {
+		if ms.Lookup(nil, name) == nil {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,233 @@
+// Copyright (C) 2023 Michael J. Fromberger. All Rights Reserved.
+
+package flax
+
+import (
+	"context"
+	"crypto/sha256"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// A Live holds a configuration value of struct type T whose fields are loaded
+// from a file, and which can be reloaded when the file changes.
+//
+// The file contains flag arguments in the format described by [ExpandArgs]:
+// for example, a line "-name value" sets the flag for the field tagged
+// "name". Fields not set by the file have their default values.
+//
+// Each load constructs a new value of type T. Readers obtain the current
+// value from [Live.Get], and a reload replaces it atomically, so readers
+// never see a partly-updated value. A reader must not modify the value.
+//
+// When a configuration is reloaded, only fields whose tags have the
+// reloadable option may change. A reload that would change any other field
+// is rejected with an error, and the current value is kept.
+type Live[T any] struct {
+	path string
+	opts []ParseOption
+
+	cur atomic.Pointer[liveValue[T]]
+
+	mu       sync.Mutex // serializes reloads and protects the fields below
+	onChange []func(old, new *T, changed []string)
+	onError  []func(error)
+}
+
+// A liveValue is a loaded configuration value and its fields.
+type liveValue[T any] struct {
+	v      *T
+	fields Fields
+	stat   os.FileInfo       // of the configuration file, before it was read
+	read   time.Time         // when the configuration file was read
+	sum    [sha256.Size]byte // of the contents of the configuration file
+}
+
+// NewLive constructs a Live value for the configuration file at path, and
+// loads its initial value. The options are passed to [Fields.Parse] on each
+// load; arguments in the file are always expanded as for [ExpandArgFiles].
+// For example, use [EnvPrefix] to let environment variables supply fields not
+// set by the file.
+func NewLive[T any](path string, opts ...ParseOption) (*Live[T], error) {
+	lv := &Live[T]{path: path, opts: opts}
+	cur, err := lv.load()
+	if err != nil {
+		return nil, err
+	}
+	lv.cur.Store(cur)
+	return lv, nil
+}
+
+// Get returns the current configuration value. The caller must not modify the
+// value; use [Live.Reload] to change it.
+func (lv *Live[T]) Get() *T { return lv.cur.Load().v }
+
+// Fields returns the fields of the current configuration value.
+func (lv *Live[T]) Fields() Fields { return lv.cur.Load().fields }
+
+// OnChange registers f to be called after each reload that changes the value
+// of at least one field. The arguments are the previous and new values, and
+// the flag names of the fields that changed, in declaration order. Callbacks
+// are called synchronously by the reloading goroutine, after the new value is
+// in place; they may register further callbacks. Callbacks for concurrent
+// reloads may run concurrently.
+func (lv *Live[T]) OnChange(f func(old, new *T, changed []string)) {
+	lv.mu.Lock()
+	defer lv.mu.Unlock()
+	lv.onChange = append(lv.onChange, f)
+}
+
+// OnError registers f to be called with the error from each failed reload
+// performed by [Live.Watch].
+func (lv *Live[T]) OnError(f func(error)) {
+	lv.mu.Lock()
+	defer lv.mu.Unlock()
+	lv.onError = append(lv.onError, f)
+}
+
+// Reload re-reads the configuration file and, if it is valid, replaces the
+// current value. Reload reports an error without changing the current value
+// if the file cannot be read or parsed, if the new value is not valid (see
+// [Fields.Validate]), or if it changes a field that is not reloadable.
+func (lv *Live[T]) Reload() error {
+	lv.mu.Lock()
+	cur, next, changed, err := lv.reload()
+	callbacks := slices.Clone(lv.onChange)
+	lv.mu.Unlock()
+
+	if err != nil || len(changed) == 0 {
+		return err
+	}
+	for _, f := range callbacks {
+		f(cur.v, next.v, changed)
+	}
+	return nil
+}
+
+// reload loads a new value and, if it is valid, replaces the current value.
+// It returns the previous and new values and the names of the fields that
+// changed. The caller must hold lv.mu.
+func (lv *Live[T]) reload() (cur, next *liveValue[T], changed []string, _ error) {
+	next, err := lv.load()
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	cur = lv.cur.Load()
+	var fixed []string
+	for i, fi := range next.fields {
+		old := cur.fields[i]
+		if old.current() == fi.current() {
+			continue
+		}
+		changed = append(changed, fi.Name)
+		if _, ok := fi.opts["reloadable"]; !ok {
+			fixed = append(fixed, fi.Name)
+		}
+	}
+	if len(fixed) != 0 {
+		return nil, nil, nil, fmt.Errorf("reload %s: cannot change non-reloadable flags: %s",
+			lv.path, strings.Join(fixed, ", "))
+	} else if len(changed) != 0 {
+		lv.cur.Store(next)
+	}
+	return cur, next, changed, nil
+}
+
+// Watch reloads the configuration whenever the configuration file changes,
+// as determined by checking it at the given interval, and on Unix systems
+// whenever the process receives SIGHUP. A file whose size and modification
+// time are unchanged is assumed not to have changed, unless it was modified
+// so recently that a rewrite might not have updated its modification time; in
+// that case Watch compares its contents. Watch runs until ctx ends, and then
+// returns. Errors from reloading are reported to the callbacks registered by
+// [Live.OnError].
+func (lv *Live[T]) Watch(ctx context.Context, interval time.Duration) {
+	hup := make(chan os.Signal, 1)
+	notifyReload(hup)
+	defer signal.Stop(hup)
+
+	tick := time.NewTicker(interval)
+	defer tick.Stop()
+
+	cur := lv.cur.Load()
+	stat, read, sum := cur.stat, cur.read, cur.sum
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+		case <-tick.C:
+			fi, err := os.Stat(lv.path)
+			if err != nil {
+				continue
+			}
+			same := fi.Size() == stat.Size() && fi.ModTime().Equal(stat.ModTime())
+			if same && read.Sub(fi.ModTime()) > mtimeSlack {
+				continue
+			}
+			now := time.Now()
+			data, err := os.ReadFile(lv.path)
+			if err != nil {
+				continue
+			}
+			next := sha256.Sum256(data)
+			stat, read = fi, now
+			if next == sum {
+				continue
+			}
+			sum = next
+		}
+		if err := lv.Reload(); err != nil {
+			lv.mu.Lock()
+			callbacks := slices.Clone(lv.onError)
+			lv.mu.Unlock()
+			for _, f := range callbacks {
+				f(err)
+			}
+		}
+	}
+}
+
+// mtimeSlack bounds the granularity of file modification times. A file
+// rewritten less than this long after it was last read may keep the same
+// size and modification time, so Watch compares its contents.
+const mtimeSlack = 2 * time.Second
+
+// load reads and parses a new value from the configuration file.
+func (lv *Live[T]) load() (*liveValue[T], error) {
+	stat, err := os.Stat(lv.path)
+	if err != nil {
+		return nil, err
+	}
+	read := time.Now()
+	data, err := os.ReadFile(lv.path)
+	if err != nil {
+		return nil, err
+	}
+	args, err := ExpandArgs([]string{"@" + lv.path})
+	if err != nil {
+		return nil, err
+	}
+	v := new(T)
+	fields, err := Check(v)
+	if err != nil {
+		return nil, err
+	}
+	fs := flag.NewFlagSet(lv.path, flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	fields.Bind(fs)
+	if err := fields.Parse(fs, args, lv.opts...); err != nil {
+		return nil, fmt.Errorf("load %s: %w", lv.path, err)
+	} else if fs.NArg() != 0 {
+		return nil, fmt.Errorf("load %s: unexpected non-flag argument %q", lv.path, fs.Arg(0))
+	}
+	return &liveValue[T]{v: v, fields: fields, stat: stat, read: read, sum: sha256.Sum256(data)}, nil
+}
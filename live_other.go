@@ -0,0 +1,10 @@
+// Copyright (C) 2023 Michael J. Fromberger. All Rights Reserved.
+
+//go:build !unix
+
+package flax
+
+import "os"
+
+// notifyReload does nothing on platforms without SIGHUP.
+func notifyReload(chan<- os.Signal) {}
@@ -0,0 +1,196 @@
+// Copyright (C) 2023 Michael J. Fromberger. All Rights Reserved.
+
+package flax_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/creachadair/flax"
+)
+
+type liveConfig struct {
+	Addr    string        `flag:"addr,default=:8080,Service address"`
+	Level   int           `flag:"level,reloadable,Log level"`
+	Timeout time.Duration `flag:"timeout,reloadable,default=5s,Request timeout"`
+}
+
+func writeConfig(t *testing.T, path, text string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(text), 0600); err != nil {
+		t.Fatalf("Write config: %v", err)
+	}
+}
+
+func TestLive(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config")
+	writeConfig(t, path, "-addr :9090\n-level 1\n")
+
+	lv, err := flax.NewLive[liveConfig](path)
+	if err != nil {
+		t.Fatalf("NewLive failed: %v", err)
+	}
+	if want := (liveConfig{Addr: ":9090", Level: 1, Timeout: 5 * time.Second}); *lv.Get() != want {
+		t.Errorf("Initial: got %+v, want %+v", *lv.Get(), want)
+	}
+
+	var gotChanged []string
+	var gotOld, gotNew liveConfig
+	lv.OnChange(func(old, new *liveConfig, changed []string) {
+		gotOld, gotNew, gotChanged = *old, *new, changed
+	})
+
+	// A reload with no changes does not call the callback.
+	if err := lv.Reload(); err != nil {
+		t.Fatalf("Reload: unexpected error: %v", err)
+	} else if gotChanged != nil {
+		t.Errorf("Reload: unexpected change %q", gotChanged)
+	}
+
+	// Reloadable fields may change.
+	first := lv.Get()
+	writeConfig(t, path, "-addr :9090\n-level 3\n-timeout 1m\n")
+	if err := lv.Reload(); err != nil {
+		t.Fatalf("Reload: unexpected error: %v", err)
+	}
+	if want := []string{"level", "timeout"}; !reflect.DeepEqual(gotChanged, want) {
+		t.Errorf("Changed: got %q, want %q", gotChanged, want)
+	}
+	if gotOld != *first || gotNew != *lv.Get() {
+		t.Errorf("OnChange: got (%+v, %+v), want (%+v, %+v)", gotOld, gotNew, *first, *lv.Get())
+	}
+	if first.Level != 1 {
+		t.Errorf("Previous value was modified: %+v", *first)
+	}
+	if want := (liveConfig{Addr: ":9090", Level: 3, Timeout: time.Minute}); *lv.Get() != want {
+		t.Errorf("Reloaded: got %+v, want %+v", *lv.Get(), want)
+	}
+
+	// Other fields may not change.
+	writeConfig(t, path, "-addr :1234\n-level 4\n")
+	if err := lv.Reload(); err == nil {
+		t.Error("Reload: got nil, want error")
+	} else if !strings.Contains(err.Error(), "non-reloadable flags: addr") {
+		t.Errorf("Reload: got %v, want non-reloadable error", err)
+	}
+	if lv.Get().Level != 3 {
+		t.Errorf("Rejected reload changed the value: %+v", *lv.Get())
+	}
+
+	// Invalid values are rejected.
+	writeConfig(t, path, "-addr :9090\n-level high\n")
+	if err := lv.Reload(); err == nil {
+		t.Error("Reload: got nil, want error")
+	}
+	writeConfig(t, path, "-addr :9090\nextra\n")
+	if err := lv.Reload(); err == nil {
+		t.Error("Reload: got nil, want error")
+	}
+	if lv.Get().Level != 3 {
+		t.Errorf("Rejected reload changed the value: %+v", *lv.Get())
+	}
+}
+
+func TestLiveNestedCallback(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config")
+	writeConfig(t, path, "-level 1\n")
+	lv, err := flax.NewLive[liveConfig](path)
+	if err != nil {
+		t.Fatalf("NewLive failed: %v", err)
+	}
+
+	// A callback may register other callbacks without deadlocking.
+	var calls int
+	lv.OnChange(func(_, _ *liveConfig, _ []string) {
+		calls++
+		lv.OnChange(func(_, _ *liveConfig, _ []string) { calls++ })
+		lv.OnError(func(error) {})
+	})
+	writeConfig(t, path, "-level 2\n")
+	if err := lv.Reload(); err != nil {
+		t.Fatalf("Reload: unexpected error: %v", err)
+	}
+	if calls != 1 {
+		t.Errorf("Callbacks: got %d calls, want 1", calls)
+	}
+	writeConfig(t, path, "-level 3\n")
+	if err := lv.Reload(); err != nil {
+		t.Fatalf("Reload: unexpected error: %v", err)
+	}
+	if calls != 3 {
+		t.Errorf("Callbacks: got %d calls, want 3", calls)
+	}
+}
+
+func TestLiveEnvPrefix(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config")
+	writeConfig(t, path, "-addr :9090\n")
+	t.Setenv("LIVE_ADDR", ":1234")
+	t.Setenv("LIVE_LEVEL", "2")
+
+	lv, err := flax.NewLive[liveConfig](path, flax.EnvPrefix("LIVE"))
+	if err != nil {
+		t.Fatalf("NewLive failed: %v", err)
+	}
+	if want := (liveConfig{Addr: ":9090", Level: 2, Timeout: 5 * time.Second}); *lv.Get() != want {
+		t.Errorf("Initial: got %+v, want %+v", *lv.Get(), want)
+	}
+}
+
+func TestLiveWatch(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config")
+	writeConfig(t, path, "-level 1\n")
+	lv, err := flax.NewLive[liveConfig](path)
+	if err != nil {
+		t.Fatalf("NewLive failed: %v", err)
+	}
+
+	var mu sync.Mutex
+	var levels []int
+	var errs []error
+	lv.OnChange(func(_, new *liveConfig, _ []string) {
+		mu.Lock()
+		defer mu.Unlock()
+		levels = append(levels, new.Level)
+	})
+	lv.OnError(func(err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		errs = append(errs, err)
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() { defer close(done); lv.Watch(ctx, 5*time.Millisecond) }()
+
+	waitFor := func(what string, cond func() bool) {
+		t.Helper()
+		for start := time.Now(); time.Since(start) < 5*time.Second; time.Sleep(5 * time.Millisecond) {
+			mu.Lock()
+			ok := cond()
+			mu.Unlock()
+			if ok {
+				return
+			}
+		}
+		t.Fatalf("Timed out waiting for %s", what)
+	}
+
+	writeConfig(t, path, "-level 2\n")
+	waitFor("reload", func() bool { return len(levels) == 1 && levels[0] == 2 })
+
+	writeConfig(t, path, "-level bogus-value\n")
+	waitFor("error", func() bool { return len(errs) == 1 })
+
+	cancel()
+	<-done
+	if lv.Get().Level != 2 {
+		t.Errorf("Level: got %d, want 2", lv.Get().Level)
+	}
+}
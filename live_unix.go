@@ -0,0 +1,14 @@
+// Copyright (C) 2023 Michael J. Fromberger. All Rights Reserved.
+
+//go:build unix
+
+package flax
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// notifyReload arranges for the signals that request a reload to be sent to c.
+func notifyReload(c chan<- os.Signal) { signal.Notify(c, syscall.SIGHUP) }
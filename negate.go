@@ -0,0 +1,141 @@
+// Copyright (C) 2023 Michael J. Fromberger. All Rights Reserved.
+
+package flax
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// negatedPrefix is the prefix of the flag name that negates a bool field
+// tagged with the negatable option.
+const negatedPrefix = "no-"
+
+// isNegatable reports whether fi has the negatable option.
+func (fi *Field) isNegatable() bool {
+	_, ok := fi.opts["negatable"]
+	return ok
+}
+
+// Negated reports whether the flag for fi was most recently set by its
+// negated form, for example "-no-cache" rather than "-cache". It reports
+// false if fi does not have the negatable option.
+func (fi *Field) Negated() bool {
+	if fi.noFlag != nil {
+		if v, ok := fi.noFlag.Value.(negatableFlag); ok {
+			return v.fi.negated // the field that bound the flag, possibly not fi
+		}
+	}
+	return fi.negated
+}
+
+// A negatableFlag is the flag.Value for either form of a negatable bool
+// field. The flag package requires a distinct value for each flag name.
+type negatableFlag struct {
+	fi  *Field
+	neg bool // this is the negated form
+}
+
+func (v negatableFlag) String() string {
+	if v.fi == nil {
+		return "false" // the flag package may call String on a zero value
+	}
+	return strconv.FormatBool(*v.fi.target.(*bool) != v.neg)
+}
+
+func (v negatableFlag) Set(s string) error {
+	b, err := strconv.ParseBool(s)
+	if err != nil {
+		return err
+	}
+	*v.fi.target.(*bool) = b != v.neg
+	v.fi.negated = v.neg
+	return nil
+}
+
+func (v negatableFlag) Get() any { return *v.fi.target.(*bool) != v.neg }
+
+func (v negatableFlag) IsBoolFlag() bool { return true }
+
+// bindNegatable registers both forms of the negatable bool field fi in fs.
+func (fi *Field) bindNegatable(fs *flag.FlagSet, usage string) {
+	fs.Var(negatableFlag{fi: fi}, fi.Name, usage)
+	fs.Var(negatableFlag{fi: fi, neg: true}, negatedPrefix+fi.Name, "Negates -"+fi.Name)
+	fi.noFlag = fs.Lookup(negatedPrefix + fi.Name)
+}
+
+// PrintDefaults prints a usage message for the flags defined in fs to the
+// output of fs, in the same format as [flag.FlagSet.PrintDefaults], except
+// that the two forms of each negatable flag in f are listed together:
+//
+//	-cache, -no-cache
+//	    	Use the cache (default true)
+//
+// Flags in fs that are not described by f are printed as usual. If any fields
+// of f have the xor, oneof, or requires options, a summary of these
+// constraints follows the flags.
+func (f Fields) PrintDefaults(fs *flag.FlagSet) {
+	negated := make(map[string]bool)
+	for _, fi := range f {
+		if fi.isNegatable() && fi.flagSet == fs {
+			negated[fi.Name] = true
+		}
+	}
+	var buf bytes.Buffer
+	fs.VisitAll(func(fl *flag.Flag) {
+		name, isNeg := strings.CutPrefix(fl.Name, negatedPrefix)
+		if isNeg && negated[name] {
+			return // listed with the positive form
+		}
+		text := printFlag(fl)
+		if negated[fl.Name] {
+			_, rest, _ := strings.Cut(text, "\t")
+			text = fmt.Sprintf("  -%[1]s, -%[2]s%[1]s\n    \t%[3]s", fl.Name, negatedPrefix, rest)
+		}
+		buf.WriteString(text)
+	})
+	f.printRelations(&buf)
+	fs.Output().Write(buf.Bytes())
+}
+
+// setUsage sets the usage function of fs to print a header naming fs, followed
+// by the flags of fs as printed by [Fields.PrintDefaults]. This matches the
+// default usage message of the flag package.
+func (f Fields) setUsage(fs *flag.FlagSet) {
+	fs.Usage = func() {
+		if fs.Name() == "" {
+			fmt.Fprintf(fs.Output(), "Usage:\n")
+		} else {
+			fmt.Fprintf(fs.Output(), "Usage of %s:\n", fs.Name())
+		}
+		f.PrintDefaults(fs)
+	}
+}
+
+// hasDefaultUsage reports whether the usage function of fs is nil, or is the
+// default installed by [flag.NewFlagSet]. Method values of the same method
+// share their code, so the default can be recognized by comparing with the
+// usage function of another new flag set.
+func hasDefaultUsage(fs *flag.FlagSet) bool {
+	if fs.Usage == nil {
+		return true
+	}
+	def := flag.NewFlagSet("", flag.ContinueOnError).Usage
+	return reflect.ValueOf(fs.Usage).Pointer() == reflect.ValueOf(def).Pointer()
+}
+
+// printFlag renders the usage text for a single flag as printed by the flag
+// package.
+func printFlag(fl *flag.Flag) string {
+	var buf bytes.Buffer
+	tmp := flag.NewFlagSet("", flag.ContinueOnError)
+	tmp.SetOutput(&buf)
+	tmp.Var(fl.Value, fl.Name, fl.Usage)
+	tmp.Lookup(fl.Name).DefValue = fl.DefValue
+	tmp.PrintDefaults()
+	return buf.String()
+}
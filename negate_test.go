@@ -0,0 +1,164 @@
+// Copyright (C) 2023 Michael J. Fromberger. All Rights Reserved.
+
+package flax_test
+
+import (
+	"errors"
+	"flag"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/creachadair/flax"
+)
+
+type negFlags struct {
+	Cache   bool `flag:"cache,negatable,default=true,Use the cache"`
+	Color   bool `flag:"color,negatable,env=TEST_NEG_COLOR,Colorize output"`
+	Verbose bool `flag:"v,Verbose"`
+}
+
+func newNegFlags(t *testing.T) (*negFlags, flax.Fields, *flag.FlagSet) {
+	t.Helper()
+	flags := new(negFlags)
+	fi := flax.MustCheck(flags)
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	fi.Bind(fs)
+	return flags, fi, fs
+}
+
+func TestNegatable(t *testing.T) {
+	tests := []struct {
+		args         []string
+		cache, color bool
+		negated      bool // of cache
+	}{
+		{nil, true, false, false},
+		{[]string{"-no-cache"}, false, false, true},
+		{[]string{"-cache=false"}, false, false, false},
+		{[]string{"-no-cache", "-cache"}, true, false, false},
+		{[]string{"-cache", "-no-cache"}, false, false, true},
+		{[]string{"-no-cache=false"}, true, false, true},
+		{[]string{"-color", "-no-color"}, true, false, false},
+		{[]string{"-color"}, true, true, false},
+	}
+	for _, tc := range tests {
+		flags, fi, fs := newNegFlags(t)
+		if err := fs.Parse(tc.args); err != nil {
+			t.Errorf("Parse %q: unexpected error: %v", tc.args, err)
+			continue
+		}
+		if flags.Cache != tc.cache || flags.Color != tc.color {
+			t.Errorf("Parse %q: got cache=%v color=%v, want %v, %v",
+				tc.args, flags.Cache, flags.Color, tc.cache, tc.color)
+		}
+		if got := fi.Flag("cache").Negated(); got != tc.negated {
+			t.Errorf("Parse %q: Negated got %v, want %v", tc.args, got, tc.negated)
+		}
+		if got, want := fi.IsSet("cache"), len(tc.args) != 0 && strings.Contains(strings.Join(tc.args, " "), "cache"); got != want {
+			t.Errorf("Parse %q: IsSet got %v, want %v", tc.args, got, want)
+		}
+	}
+
+	_, fi, fs := newNegFlags(t)
+	if fi.Flag("no-cache") != fi.Flag("cache") || fi.Flag("cache") == nil {
+		t.Error("Flag(no-cache) did not find the cache field")
+	}
+	if fi.Flag("no-v") != nil {
+		t.Error("Flag(no-v) found a field for a non-negatable flag")
+	}
+	if err := fs.Parse([]string{"-no-v"}); err == nil {
+		t.Error("Parse -no-v: got nil, want error")
+	}
+
+	var bad struct {
+		N int `flag:"n,negatable,Not a bool"`
+	}
+	if _, err := flax.Check(&bad); err == nil || !strings.Contains(err.Error(), "negatable option requires") {
+		t.Errorf("Check: got %v, want negatable option error", err)
+	}
+}
+
+func TestNegatableEnv(t *testing.T) {
+	t.Setenv("TEST_NEG_COLOR", "true")
+
+	flags, fi, fs := newNegFlags(t)
+	if err := fi.Parse(fs, []string{"-no-color"}); err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if flags.Color {
+		t.Error("Color: environment overrode -no-color")
+	}
+
+	flags, fi, fs = newNegFlags(t)
+	if err := fi.Parse(fs, nil); err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if !flags.Color {
+		t.Error("Color: environment was not applied")
+	}
+}
+
+func TestPrintDefaults(t *testing.T) {
+	_, fi, fs := newNegFlags(t)
+	fs.Int("other", 3, "Another flag")
+
+	var out strings.Builder
+	fs.SetOutput(&out)
+	fi.PrintDefaults(fs)
+	const want = `  -cache, -no-cache
+    	Use the cache (default true)
+  -color, -no-color
+    	Colorize output [env: TEST_NEG_COLOR]
+  -other int
+    	Another flag (default 3)
+  -v	Verbose
+`
+	if got := out.String(); got != want {
+		t.Errorf("PrintDefaults:\ngot:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestHelpNegatable(t *testing.T) {
+	const want = `  -cache, -no-cache
+    	Use the cache (default true)
+`
+	check := func(t *testing.T, out string) {
+		t.Helper()
+		if !strings.Contains(out, want) {
+			t.Errorf("Help output: missing %q:\n%s", want, out)
+		}
+		if n := strings.Count(out, "-no-cache"); n != 1 {
+			t.Errorf("Help output: -no-cache listed %d times, want 1:\n%s", n, out)
+		}
+	}
+
+	t.Run("Parse", func(t *testing.T) {
+		var out strings.Builder
+		_, _, err := flax.Parse[negFlags]([]string{"-h"}, flax.ParseOutput(&out))
+		if !errors.Is(err, flag.ErrHelp) {
+			t.Fatalf("Parse -h: got %v, want %v", err, flag.ErrHelp)
+		}
+		check(t, out.String())
+	})
+	t.Run("MustBind", func(t *testing.T) {
+		var out strings.Builder
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		fs.SetOutput(&out)
+		flax.MustBind(fs, new(negFlags))
+		if err := fs.Parse([]string{"-h"}); !errors.Is(err, flag.ErrHelp) {
+			t.Fatalf("Parse -h: got %v, want %v", err, flag.ErrHelp)
+		}
+		check(t, out.String())
+	})
+	t.Run("Custom", func(t *testing.T) {
+		var called bool
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		fs.Usage = func() { called = true }
+		flax.MustBind(fs, new(negFlags))
+		if fs.Usage(); !called {
+			t.Error("MustBind replaced a custom usage function")
+		}
+	})
+}
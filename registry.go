@@ -0,0 +1,144 @@
+// Copyright (C) 2023 Michael J. Fromberger. All Rights Reserved.
+
+package flax
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// A Registry maps field types to functions that parse and format their flag
+// values. A field whose type is registered is flaggable even if its type is
+// not otherwise compatible, and a registration takes precedence over the
+// built-in handling of the type. The zero value is ready for use.
+//
+// Types registered with [RegisterType] are available to all calls to
+// [Check]. To avoid conflicts between packages, a library that needs custom
+// types for its own flags can instead register them in a Registry of its own
+// and use its [Registry.Check] method.
+type Registry struct {
+	mu    sync.RWMutex
+	types map[reflect.Type]*typeCodec
+}
+
+// A typeCodec parses and formats the values of a registered type. The target
+// arguments are pointers to values of the type.
+type typeCodec struct {
+	set    func(target any, s string) error
+	get    func(target any) any
+	format func(target any) string
+	isBool bool // the flag does not require a value
+}
+
+// globalTypes is the registry used by RegisterType.
+var globalTypes Registry
+
+// RegisterType registers parse and format functions for flags of type T in
+// the global registry, used by [Check] and [NewField]. If format == nil,
+// values are formatted using [fmt.Sprint]. It panics if parse == nil, or if T
+// is already registered.
+//
+// RegisterType is intended to be called during program initialization.
+func RegisterType[T any](parse func(string) (T, error), format func(T) string) {
+	Register(&globalTypes, parse, format)
+}
+
+// Register registers parse and format functions for flags of type T in r, as
+// [RegisterType] does for the global registry. A type registered in r takes
+// precedence over the same type in the global registry for flags checked by
+// r.
+func Register[T any](r *Registry, parse func(string) (T, error), format func(T) string) {
+	if parse == nil {
+		panic("flax: nil parse function")
+	}
+	if format == nil {
+		format = func(v T) string { return fmt.Sprint(v) }
+	}
+	rt := reflect.TypeFor[T]()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.types[rt]; ok {
+		panic(fmt.Sprintf("flax: type %v is already registered", rt))
+	}
+	if r.types == nil {
+		r.types = make(map[reflect.Type]*typeCodec)
+	}
+	r.types[rt] = newCodec(parse, format)
+}
+
+// newCodec returns a typeCodec for T using the given functions.
+func newCodec[T any](parse func(string) (T, error), format func(T) string) *typeCodec {
+	return &typeCodec{
+		set: func(target any, s string) error {
+			v, err := parse(s)
+			if err != nil {
+				return err
+			}
+			*target.(*T) = v
+			return nil
+		},
+		get:    func(target any) any { return *target.(*T) },
+		format: func(target any) string { return format(*target.(*T)) },
+	}
+}
+
+// Check constructs information about the flaggable fields of v, as [Check]
+// does, but also accepts fields whose types are registered in r.
+func (r *Registry) Check(v any) (Fields, error) {
+	rv, err := structValue(v)
+	if err != nil {
+		return nil, err
+	}
+	return checkValue(rv, checkType(rv.Type()), r)
+}
+
+// lookup returns the codec for rt in r, the global registry, or the standard
+// library types, in that order of precedence, or nil if rt is not found in
+// any of them. The opts are the tag options of the field.
+func (r *Registry) lookup(rt reflect.Type, opts map[string]string) (*typeCodec, error) {
+	if r != nil && r != &globalTypes {
+		if c := r.find(rt); c != nil {
+			return c, nil
+		}
+	}
+	if c := globalTypes.find(rt); c != nil {
+		return c, nil
+	} else if f, ok := stdTypes[rt]; ok {
+		return f(opts)
+	}
+	return nil, nil
+}
+
+func (r *Registry) find(rt reflect.Type) *typeCodec {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.types[rt]
+}
+
+// A registeredValue implements the flag.Getter interface for a field whose
+// type is registered.
+type registeredValue struct {
+	target any // pointer to the field value
+	codec  *typeCodec
+}
+
+func (v *registeredValue) String() string {
+	if v == nil || v.codec == nil {
+		return "" // the flag package may call String on a zero value
+	}
+	return v.codec.format(v.target)
+}
+
+func (v *registeredValue) Set(s string) error {
+	if v.codec == nil {
+		return errors.New("flag value is not initialized")
+	}
+	return v.codec.set(v.target, s)
+}
+
+func (v *registeredValue) Get() any { return v.codec.get(v.target) }
+
+func (v *registeredValue) IsBoolFlag() bool { return v.codec != nil && v.codec.isBool }
@@ -0,0 +1,132 @@
+// Copyright (C) 2023 Michael J. Fromberger. All Rights Reserved.
+
+package flax_test
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"math/big"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/creachadair/flax"
+)
+
+type point struct{ X, Y int }
+
+func parsePoint(s string) (point, error) {
+	var p point
+	_, err := fmt.Sscanf(s, "%d,%d", &p.X, &p.Y)
+	return p, err
+}
+
+func formatPoint(p point) string { return fmt.Sprintf("%d,%d", p.X, p.Y) }
+
+var registerPoint sync.Once
+
+func TestRegisterType(t *testing.T) {
+	registerPoint.Do(func() { flax.RegisterType(parsePoint, formatPoint) })
+
+	var flags struct {
+		Origin point `flag:"origin,default='3,4',Origin"`
+		Other  point `flag:"other,Other point"`
+	}
+	fi, err := flax.Check(&flags)
+	if err != nil {
+		t.Fatalf("Check failed: %v", err)
+	}
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	fi.Bind(fs)
+	if got, want := fi.Flag("origin").Default(), "3,4"; got != want {
+		t.Errorf("Default: got %q, want %q", got, want)
+	}
+	if err := fs.Parse([]string{"-other", "5,6"}); err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if want := (point{3, 4}); flags.Origin != want {
+		t.Errorf("Origin: got %+v, want %+v", flags.Origin, want)
+	}
+	if want := (point{5, 6}); flags.Other != want {
+		t.Errorf("Other: got %+v, want %+v", flags.Other, want)
+	}
+	if got := fi.Flag("other").Value().Get(); got != (point{5, 6}) {
+		t.Errorf("Get: got %#v, want point{5, 6}", got)
+	}
+	if err := fs.Parse([]string{"-other", "bogus"}); err == nil {
+		t.Error("Parse bogus: got nil, want error")
+	}
+
+	fi.Reset()
+	if want := (point{3, 4}); flags.Origin != want || flags.Other != (point{}) {
+		t.Errorf("Reset: got %+v, want origin %+v and zero other", flags, want)
+	}
+
+	var out strings.Builder
+	fs.SetOutput(&out)
+	fs.PrintDefaults()
+	if got := out.String(); !strings.Contains(got, "(default 3,4)") {
+		t.Errorf("Usage: got %q, want default 3,4", got)
+	}
+
+	mustPanic(t, "duplicate", func() { flax.RegisterType(parsePoint, nil) })
+	mustPanic(t, "nil parse", func() { flax.RegisterType[*big.Int](nil, nil) })
+}
+
+func TestRegistry(t *testing.T) {
+	registerPoint.Do(func() { flax.RegisterType(parsePoint, formatPoint) })
+
+	var flags struct {
+		N *big.Int `flag:"n,default=12345678901234567890,A big number"`
+		P point    `flag:"p,default='1,2',A point"`
+	}
+	if fi, err := flax.Check(&flags); err == nil {
+		t.Fatalf("Check: got %v, want error for unregistered type", fi)
+	}
+
+	var r flax.Registry
+	flax.Register(&r, func(s string) (*big.Int, error) {
+		z, ok := new(big.Int).SetString(s, 10)
+		if !ok {
+			return nil, fmt.Errorf("invalid integer %q", s)
+		}
+		return z, nil
+	}, func(z *big.Int) string { return z.String() })
+	flax.Register(&r, func(s string) (point, error) {
+		p, err := parsePoint(s)
+		return point{p.Y, p.X}, err // scoped registrations take precedence
+	}, nil)
+
+	fi, err := r.Check(&flags)
+	if err != nil {
+		t.Fatalf("Check failed: %v", err)
+	}
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fi.Bind(fs)
+	if got, want := flags.N.String(), "12345678901234567890"; got != want {
+		t.Errorf("N: got %s, want %s", got, want)
+	}
+	if want := (point{2, 1}); flags.P != want {
+		t.Errorf("P: got %+v, want %+v", flags.P, want)
+	}
+	if got, want := fi.Flag("p").Default(), "{2 1}"; got != want {
+		t.Errorf("P default: got %q, want %q", got, want)
+	}
+	if err := fs.Parse([]string{"-n", "-5"}); err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	} else if flags.N.Int64() != -5 {
+		t.Errorf("N: got %v, want -5", flags.N)
+	}
+}
+
+func mustPanic(t *testing.T, what string, f func()) {
+	t.Helper()
+	defer func() {
+		if recover() == nil {
+			t.Errorf("%s: did not panic", what)
+		}
+	}()
+	f()
+}
@@ -0,0 +1,99 @@
+// Copyright (C) 2023 Michael J. Fromberger. All Rights Reserved.
+
+package flax
+
+import "reflect"
+
+// Reset restores each field of f to its default value. See [Field.Reset].
+func (f Fields) Reset() {
+	for _, fi := range f {
+		fi.Reset()
+	}
+}
+
+// ResetEnv restores each field of f to its default value, as [Fields.Reset],
+// but first re-reads the default value of each field whose default is taken
+// from an environment variable. It reports an error if the value of any such
+// variable is not valid for its field; in that case f may be partially reset.
+func (f Fields) ResetEnv() error {
+	for _, fi := range f {
+		if fi.env == "" {
+			continue
+		}
+		fv := fi.targetValue()
+		fv.Set(copyValue(fi.base))
+		nfi, err := newField(FieldSpec{
+			Field:   fi.field.Name,
+			Name:    fi.Name,
+			Usage:   fi.Usage,
+			Options: fi.opts,
+		}, fi.field, fv, fi.reg)
+		if err != nil {
+			return err
+		}
+		fi.dvalue, fi.dtext, fi.dsnap = nfi.dvalue, nfi.dtext, nfi.dsnap
+	}
+	f.Reset()
+	return nil
+}
+
+// Reset restores the target field of fi to its default value, as resolved
+// when fi was constructed by [Check]. This allows a struct to be re-used for
+// repeated flag parsing. The default value of a field whose type implements
+// [flag.Value] or the text marshaling interfaces is the state of the field
+// after its default was applied; slice and map values and the exported slice
+// and map fields of struct values are copied, so that changes to the field
+// after Reset do not affect the stored default.
+//
+// Reset does not affect which flags a [flag.FlagSet] records as having been
+// set.
+func (fi *Field) Reset() {
+	fi.targetValue().Set(copyValue(fi.dsnap))
+}
+
+// Snapshot records the current value of the target field of fi, and returns a
+// function that restores the field to that value. The value is copied as for
+// [Field.Reset], so later changes to the field, such as appending to a slice,
+// do not affect the snapshot.
+func (fi *Field) Snapshot() (restore func()) {
+	snap := copyValue(fi.targetValue())
+	return func() { fi.targetValue().Set(copyValue(snap)) }
+}
+
+// copyValue returns a copy of v. Slices and maps are copied, as are the
+// exported fields of structs, so that the result does not share storage with
+// v for those values.
+func copyValue(v reflect.Value) reflect.Value {
+	switch v.Kind() {
+	case reflect.Slice:
+		if v.IsNil() {
+			break
+		}
+		c := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		reflect.Copy(c, v)
+		return c
+
+	case reflect.Map:
+		if v.IsNil() {
+			break
+		}
+		c := reflect.MakeMapWithSize(v.Type(), v.Len())
+		for it := v.MapRange(); it.Next(); {
+			c.SetMapIndex(it.Key(), it.Value())
+		}
+		return c
+
+	case reflect.Struct:
+		c := reflect.New(v.Type()).Elem()
+		c.Set(v)
+		for i := 0; i < c.NumField(); i++ {
+			if f := c.Field(i); f.CanSet() {
+				f.Set(copyValue(v.Field(i)))
+			}
+		}
+		return c
+	}
+	c := reflect.New(v.Type()).Elem()
+	c.Set(v)
+	return c
+}
@@ -0,0 +1,137 @@
+// Copyright (C) 2023 Michael J. Fromberger. All Rights Reserved.
+
+package flax_test
+
+import (
+	"flag"
+	"maps"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/creachadair/flax"
+)
+
+// listValue is a flag.Value that accumulates repeated flags into a slice.
+type listValue []string
+
+func (v listValue) String() string      { return strings.Join(v, ",") }
+func (v *listValue) Set(s string) error { *v = append(*v, s); return nil }
+
+// mapValue is a flag.Value that accumulates key=value pairs into a map.
+type mapValue map[string]string
+
+func (v mapValue) String() string { return strings.Join(slices.Sorted(maps.Keys(v)), ",") }
+
+func (v *mapValue) Set(s string) error {
+	key, val, _ := strings.Cut(s, "=")
+	if *v == nil {
+		*v = make(mapValue)
+	}
+	(*v)[key] = val
+	return nil
+}
+
+func TestReset(t *testing.T) {
+	flags := struct {
+		Count int       `flag:"count,default=3,Count"`
+		Name  string    `flag:"name,default=*,Name"`
+		Text  textFlag  `flag:"text,default=plain,Text"`
+		List  listValue `flag:"list,default=*,List"`
+		Map   mapValue  `flag:"map,default=a=1,Map"`
+	}{Name: "self", List: listValue{"x", "y"}}
+	fi := flax.MustCheck(&flags)
+
+	for i := 0; i < 3; i++ {
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		fi.Bind(fs)
+		if err := fs.Parse([]string{
+			"-count=7", "-name=other", "-text=fancy", "-list=z", "-map=b=2",
+		}); err != nil {
+			t.Fatalf("Parse failed: %v", err)
+		}
+		if got := flags.List.String(); got != "x,y,z" {
+			t.Errorf("Pass %d: List: got %q, want x,y,z", i+1, got)
+		}
+		if got := flags.Map.String(); got != "a,b" {
+			t.Errorf("Pass %d: Map: got %q, want a,b", i+1, got)
+		}
+
+		fi.Reset()
+		if flags.Count != 3 {
+			t.Errorf("Reset Count: got %d, want 3", flags.Count)
+		}
+		if flags.Name != "self" {
+			t.Errorf("Reset Name: got %q, want self", flags.Name)
+		}
+		if flags.Text.value != "plain" {
+			t.Errorf("Reset Text: got %q, want plain", flags.Text.value)
+		}
+		if got := flags.List.String(); got != "x,y" {
+			t.Errorf("Reset List: got %q, want x,y", got)
+		}
+		if got := flags.Map.String(); got != "a" {
+			t.Errorf("Reset Map: got %q, want a", got)
+		}
+	}
+}
+
+func TestSnapshot(t *testing.T) {
+	flags := struct {
+		List listValue `flag:"list,List"`
+		Map  mapValue  `flag:"map,Map"`
+	}{List: listValue{"x"}, Map: mapValue{"a": "1"}}
+	fi := flax.MustCheck(&flags)
+	list, m := fi.Flag("list"), fi.Flag("map")
+
+	restoreList, restoreMap := list.Snapshot(), m.Snapshot()
+	for i := 0; i < 2; i++ {
+		list.Value().Set("y")
+		m.Value().Set("b=2")
+		if got := flags.List.String(); got != "x,y" {
+			t.Errorf("Pass %d: List: got %q, want x,y", i+1, got)
+		}
+		restoreList()
+		restoreMap()
+		if got := flags.List.String(); got != "x" {
+			t.Errorf("Pass %d: restored List: got %q, want x", i+1, got)
+		}
+		if got := flags.Map.String(); got != "a" {
+			t.Errorf("Pass %d: restored Map: got %q, want a", i+1, got)
+		}
+	}
+}
+
+func TestResetEnv(t *testing.T) {
+	t.Setenv("RESET_COUNT", "5")
+	var flags struct {
+		Count int    `flag:"count,default=$RESET_COUNT,Count"`
+		Name  string `flag:"name,default=fixed,Name"`
+	}
+	fi := flax.MustCheck(&flags)
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fi.Bind(fs)
+	if flags.Count != 5 {
+		t.Errorf("Count: got %d, want 5", flags.Count)
+	}
+
+	flags.Name = "changed"
+	t.Setenv("RESET_COUNT", "9")
+	if err := fi.ResetEnv(); err != nil {
+		t.Fatalf("ResetEnv: unexpected error: %v", err)
+	}
+	if flags.Count != 9 {
+		t.Errorf("Count: got %d, want 9", flags.Count)
+	}
+	if got := fi.Flag("count").Default(); got != "9" {
+		t.Errorf("Count default: got %q, want 9", got)
+	}
+	if flags.Name != "fixed" {
+		t.Errorf("Name: got %q, want fixed", flags.Name)
+	}
+
+	t.Setenv("RESET_COUNT", "bogus")
+	if err := fi.ResetEnv(); err == nil {
+		t.Error("ResetEnv: got nil, want error")
+	}
+}
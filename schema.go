@@ -0,0 +1,138 @@
+// Copyright (C) 2023 Michael J. Fromberger. All Rights Reserved.
+
+package flax
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// A Schema describes the flaggable fields of a struct type, independent of any
+// particular value of that type. A Schema can be inspected without a value,
+// and can be used to construct independent [Fields] for any number of values
+// of its type.
+type Schema struct {
+	rt reflect.Type
+	ti *typeInfo
+}
+
+// SchemaOf returns the Schema for the struct type T. It is shorthand for
+// calling [NewSchema] with the type of T.
+func SchemaOf[T any]() (*Schema, error) { return NewSchema(reflect.TypeFor[T]()) }
+
+// NewSchema returns the Schema for the struct type t, or a pointer to it.  It
+// reports an error if t is not a struct type, or if it does not define any
+// valid flaggable fields as described by [Check].
+//
+// Defaults that depend on a value (such as "*") or the environment are not
+// resolved by the schema, and are reported as written in the field tags.
+func NewSchema(t reflect.Type) (*Schema, error) {
+	if t == nil {
+		return nil, errors.New("type is nil")
+	} else if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("type %v is not a struct", t)
+	}
+	ti := checkType(t)
+
+	// Check a zero value to verify that the field types and defaults are valid.
+	// Defaults that depend on the value or the environment are skipped, since
+	// they are not resolved until a value is checked.
+	static := &typeInfo{errs: ti.errs}
+	for _, fs := range ti.fields {
+		if d, ok := fs.spec.Options["default"]; ok && isDynamicDefault(d) {
+			fs.spec.Options = maps.Clone(fs.spec.Options)
+			delete(fs.spec.Options, "default")
+		}
+		static.fields = append(static.fields, fs)
+	}
+	if _, err := checkValue(reflect.New(t).Elem(), static, nil); err != nil {
+		return nil, err
+	}
+	return &Schema{rt: t, ti: ti}, nil
+}
+
+// isDynamicDefault reports whether the default value d depends on the value
+// of a field ("*") or the environment ("$VAR").
+func isDynamicDefault(d string) bool {
+	return d == "*" || (strings.HasPrefix(d, "$") && !strings.HasPrefix(d, "$$"))
+}
+
+// Type reports the struct type described by s.
+func (s *Schema) Type() reflect.Type { return s.rt }
+
+// A SchemaField describes a flaggable field of a struct type.
+type SchemaField struct {
+	FieldSpec
+
+	Type  reflect.Type // the type of the field
+	Index []int        // the index sequence of the field
+}
+
+// Fields returns a description of each flaggable field of s, in declaration
+// order. The caller may modify the result without affecting s.
+func (s *Schema) Fields() []SchemaField {
+	out := make([]SchemaField, len(s.ti.fields))
+	for i, fs := range s.ti.fields {
+		spec := fs.spec
+		spec.Options = maps.Clone(spec.Options)
+		out[i] = SchemaField{
+			FieldSpec: spec,
+			Type:      fs.field.Type,
+			Index:     slices.Clone(fs.field.Index),
+		}
+	}
+	return out
+}
+
+// Flag returns the description of the field of s whose flag name is name,
+// and reports whether it was found.
+func (s *Schema) Flag(name string) (SchemaField, bool) {
+	for _, f := range s.Fields() {
+		if f.Name == name {
+			return f, true
+		}
+	}
+	return SchemaField{}, false
+}
+
+// Check constructs information about the flaggable fields of v, as [Check].
+// The concrete type of v must be a pointer to a value of the type of s. Each
+// call to Check returns independent Fields, which may be modified and bound
+// without affecting others.
+func (s *Schema) Check(v any) (Fields, error) {
+	rp := reflect.ValueOf(v)
+	if rp.Kind() != reflect.Pointer || rp.Type().Elem() != s.rt {
+		return nil, fmt.Errorf("value of type %T is not a pointer to %v", v, s.rt)
+	} else if rp.IsNil() {
+		return nil, errors.New("value is nil")
+	}
+	return checkValue(rp.Elem(), s.ti, nil)
+}
+
+// New constructs a new zero value of the type of s, and returns a pointer to
+// it along with its fields, as [Schema.Check].
+func (s *Schema) New() (any, Fields, error) {
+	v := reflect.New(s.rt).Interface()
+	fields, err := s.Check(v)
+	if err != nil {
+		return nil, nil, err
+	}
+	return v, fields, nil
+}
+
+// AddPrefix adds prefix to the front of the flag name of each field of f, and
+// returns f. This is useful for binding several values of the same type to a
+// single flag set under different names.
+func (f Fields) AddPrefix(prefix string) Fields {
+	for _, fi := range f {
+		fi.Name = prefix + fi.Name
+	}
+	return f
+}
@@ -0,0 +1,151 @@
+// Copyright (C) 2023 Michael J. Fromberger. All Rights Reserved.
+
+package flax_test
+
+import (
+	"flag"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/creachadair/flax"
+)
+
+type serverFlags struct {
+	Addr    string        `flag:"addr,default=localhost:8080,Service address"`
+	Timeout time.Duration `flag:"timeout,default=*,Request timeout"`
+	Debug   bool          `flag:"debug,Enable debugging"`
+	Other   int
+}
+
+func TestSchema(t *testing.T) {
+	s, err := flax.SchemaOf[serverFlags]()
+	if err != nil {
+		t.Fatalf("SchemaOf failed: %v", err)
+	}
+	if got, want := s.Type(), reflect.TypeOf(serverFlags{}); got != want {
+		t.Errorf("Type: got %v, want %v", got, want)
+	}
+
+	fields := s.Fields()
+	var names []string
+	for _, f := range fields {
+		names = append(names, f.Name)
+	}
+	if want := []string{"addr", "timeout", "debug"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("Fields: got %q, want %q", names, want)
+	}
+	if f, ok := s.Flag("timeout"); !ok {
+		t.Error(`Flag "timeout" not found`)
+	} else {
+		if f.Field != "Timeout" || f.Options["default"] != "*" {
+			t.Errorf(`Flag "timeout": got %+v, want field Timeout with default "*"`, f)
+		}
+		if f.Type != reflect.TypeOf(time.Duration(0)) {
+			t.Errorf(`Flag "timeout" type: got %v, want time.Duration`, f.Type)
+		}
+		if !reflect.DeepEqual(f.Index, []int{1}) {
+			t.Errorf(`Flag "timeout" index: got %v, want [1]`, f.Index)
+		}
+	}
+	if _, ok := s.Flag("other"); ok {
+		t.Error(`Flag "other" unexpectedly found`)
+	}
+
+	// Modifying the result should not affect the schema.
+	fields[0].Options["default"] = "bogus"
+	if f, _ := s.Flag("addr"); f.Options["default"] != "localhost:8080" {
+		t.Errorf(`Flag "addr" default: got %q, want localhost:8080`, f.Options["default"])
+	}
+}
+
+func TestSchemaInstances(t *testing.T) {
+	s, err := flax.NewSchema(reflect.TypeOf(&serverFlags{}))
+	if err != nil {
+		t.Fatalf("NewSchema failed: %v", err)
+	}
+
+	// Bind two values of the same type to one flag set with different prefixes.
+	public := serverFlags{Timeout: time.Second}
+	admin := serverFlags{Timeout: time.Minute}
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	for _, b := range []struct {
+		prefix string
+		value  *serverFlags
+	}{{"public-", &public}, {"admin-", &admin}} {
+		fi, err := s.Check(b.value)
+		if err != nil {
+			t.Fatalf("Check failed: %v", err)
+		}
+		fi.AddPrefix(b.prefix).Bind(fs)
+	}
+	if err := fs.Parse([]string{"-public-addr", ":80", "-admin-debug"}); err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	if want := (serverFlags{Addr: ":80", Timeout: time.Second}); public != want {
+		t.Errorf("Public: got %+v, want %+v", public, want)
+	}
+	if want := (serverFlags{Addr: "localhost:8080", Timeout: time.Minute, Debug: true}); admin != want {
+		t.Errorf("Admin: got %+v, want %+v", admin, want)
+	}
+
+	v, fi, err := s.New()
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	if _, ok := v.(*serverFlags); !ok || len(fi) != 3 {
+		t.Errorf("New: got (%T, %d fields), want (*serverFlags, 3 fields)", v, len(fi))
+	}
+}
+
+func TestSchemaErrors(t *testing.T) {
+	for _, typ := range []reflect.Type{
+		nil,
+		reflect.TypeOf(0),
+		reflect.TypeOf(struct{}{}),
+		reflect.TypeOf(struct {
+			X []byte `flag:"x,Bad type"`
+		}{}),
+		reflect.TypeOf(struct {
+			X int `flag:"x,default=bad,Bad default"`
+		}{}),
+		reflect.TypeOf(struct {
+			X int `flag:"x"`
+		}{}),
+	} {
+		if s, err := flax.NewSchema(typ); err == nil {
+			t.Errorf("NewSchema(%v): got %+v, want error", typ, s)
+		}
+	}
+
+	s, err := flax.SchemaOf[serverFlags]()
+	if err != nil {
+		t.Fatalf("SchemaOf failed: %v", err)
+	}
+	for _, v := range []any{nil, serverFlags{}, new(int), (*serverFlags)(nil)} {
+		if fi, err := s.Check(v); err == nil {
+			t.Errorf("Check(%T): got %+v, want error", v, fi)
+		}
+	}
+}
+
+func TestSchemaEnvDefault(t *testing.T) {
+	t.Setenv("TEST_SCHEMA_PORT", "not a number")
+
+	type envFlags struct {
+		Port int `flag:"port,default=$TEST_SCHEMA_PORT,Port"`
+	}
+	s, err := flax.SchemaOf[envFlags]()
+	if err != nil {
+		t.Fatalf("SchemaOf: unexpected error: %v", err)
+	}
+	if f, _ := s.Flag("port"); f.Options["default"] != "$TEST_SCHEMA_PORT" {
+		t.Errorf("Default: got %q, want $TEST_SCHEMA_PORT", f.Options["default"])
+	}
+
+	// The environment is consulted when a value is checked.
+	if _, _, err := s.New(); err == nil {
+		t.Error("New: got nil, want error for invalid environment default")
+	}
+}
@@ -0,0 +1,143 @@
+// Copyright (C) 2023 Michael J. Fromberger. All Rights Reserved.
+
+package flax
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// A Size is a quantity of bytes. As a flag, a Size is written as a number
+// with an optional unit suffix, for example "512", "64KiB", "10MB", or
+// "1.5G". Binary (IEC) units KiB, MiB, GiB, TiB, PiB, and EiB are powers of
+// 1024; decimal (SI) units KB, MB, GB, TB, PB, and EB, and their abbreviations
+// K, M, G, T, P, and E, are powers of 1000. A suffix "B" means bytes. Units
+// are not case sensitive, and may be separated from the number by spaces. The
+// value must be a whole number of bytes.
+//
+// A Size is rendered in the largest unit that represents it exactly.
+type Size int64
+
+// Common sizes.
+const (
+	Byte Size = 1
+	KB   Size = 1000 * Byte
+	MB   Size = 1000 * KB
+	GB   Size = 1000 * MB
+	TB   Size = 1000 * GB
+	KiB  Size = 1024 * Byte
+	MiB  Size = 1024 * KiB
+	GiB  Size = 1024 * MiB
+	TiB  Size = 1024 * GiB
+)
+
+// ParseSize parses a string in the format described by [Size].
+func ParseSize(s string) (Size, error) {
+	v, err := parseSize(s, math.MaxInt64)
+	return Size(v), err
+}
+
+// String renders z in the largest unit that represents it exactly.
+func (z Size) String() string { return formatSize(uint64(max(z, -z)), z < 0) }
+
+// Set implements part of the [flag.Value] interface.
+func (z *Size) Set(s string) error {
+	v, err := ParseSize(s)
+	if err != nil {
+		return err
+	}
+	*z = v
+	return nil
+}
+
+// Get implements part of the [flag.Getter] interface.
+func (z *Size) Get() any { return *z }
+
+// sizeUnits are the units understood by parseSize, in decreasing order of
+// magnitude. The empty unit is handled separately.
+var sizeUnits = []struct {
+	name string
+	size uint64
+}{
+	{"EiB", 1 << 60}, {"EB", 1e18},
+	{"PiB", 1 << 50}, {"PB", 1e15},
+	{"TiB", 1 << 40}, {"TB", 1e12},
+	{"GiB", 1 << 30}, {"GB", 1e9},
+	{"MiB", 1 << 20}, {"MB", 1e6},
+	{"KiB", 1 << 10}, {"KB", 1e3},
+}
+
+// sizeRE matches a size: a non-negative decimal number and a unit.
+var sizeRE = regexp.MustCompile(`^([0-9]+(?:\.[0-9]*)?|\.[0-9]+)\s*([a-zA-Z]*)$`)
+
+// parseSize parses s as a size in bytes, which must not exceed limit.
+func parseSize(s string, limit uint64) (uint64, error) {
+	m := sizeRE.FindStringSubmatch(strings.TrimSpace(s))
+	if m == nil {
+		return 0, fmt.Errorf("invalid size %q", s)
+	}
+	unit, ok := sizeUnit(m[2])
+	if !ok {
+		return 0, fmt.Errorf("invalid size unit %q", m[2])
+	}
+	r, _ := new(big.Rat).SetString(m[1])
+	r.Mul(r, new(big.Rat).SetUint64(unit))
+	if !r.IsInt() {
+		return 0, fmt.Errorf("size %q is not a whole number of bytes", s)
+	} else if n := r.Num(); !n.IsUint64() || n.Uint64() > limit {
+		return 0, fmt.Errorf("size %q is out of range", s)
+	} else {
+		return n.Uint64(), nil
+	}
+}
+
+// sizeUnit reports the number of bytes denoted by the given unit name.
+func sizeUnit(name string) (uint64, bool) {
+	switch strings.ToUpper(name) {
+	case "", "B":
+		return 1, true
+	}
+	for _, u := range sizeUnits {
+		if strings.EqualFold(name, u.name) || strings.EqualFold(name, u.name[:1]) && u.name[1] == 'B' {
+			return u.size, true
+		}
+	}
+	return 0, false
+}
+
+// formatSize renders a size of n bytes, with a leading "-" if neg is true.
+func formatSize(n uint64, neg bool) string {
+	var sign string
+	if neg {
+		sign = "-"
+	}
+	if n != 0 {
+		for _, u := range sizeUnits {
+			if n%u.size == 0 {
+				return sign + strconv.FormatUint(n/u.size, 10) + u.name
+			}
+		}
+	}
+	return sign + strconv.FormatUint(n, 10)
+}
+
+// sizeCodec returns a codec for an integer field tagged with the size option.
+// The caller must ensure the field type supports it.
+func sizeCodec(target any) *typeCodec {
+	switch target.(type) {
+	case *int64:
+		return newCodec(func(s string) (int64, error) {
+			v, err := parseSize(s, math.MaxInt64)
+			return int64(v), err
+		}, func(v int64) string { return Size(v).String() })
+	case *uint64:
+		return newCodec(func(s string) (uint64, error) {
+			return parseSize(s, math.MaxUint64)
+		}, func(v uint64) string { return formatSize(v, false) })
+	}
+	panic(fmt.Sprintf("size option cannot apply to %T", target))
+}
@@ -0,0 +1,140 @@
+// Copyright (C) 2023 Michael J. Fromberger. All Rights Reserved.
+
+package flax_test
+
+import (
+	"flag"
+	"io"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/creachadair/flax"
+)
+
+func TestParseSize(t *testing.T) {
+	tests := []struct {
+		input string
+		want  flax.Size
+		err   string
+	}{
+		{"0", 0, ""},
+		{"512", 512, ""},
+		{"512B", 512, ""},
+		{"64KiB", 64 * flax.KiB, ""},
+		{"64kib", 64 * flax.KiB, ""},
+		{"10MB", 10 * flax.MB, ""},
+		{"10 mb", 10 * flax.MB, ""},
+		{"1.5G", 1500 * flax.MB, ""},
+		{"1.5GiB", 1536 * flax.MiB, ""},
+		{".5K", 500, ""},
+		{"2T", 2 * flax.TB, ""},
+		{"8EiB", 0, "out of range"},
+		{"7EiB", 7 << 60, ""},
+		{"9223372036854775807", math.MaxInt64, ""},
+		{"9223372036854775808", 0, "out of range"},
+
+		{"", 0, "invalid size"},
+		{"-1", 0, "invalid size"},
+		{"1e3", 0, "invalid size"},
+		{"KB", 0, "invalid size"},
+		{"3 bytes", 0, "invalid size unit"},
+		{"1.0001KB", 0, "whole number"},
+	}
+	for _, tc := range tests {
+		got, err := flax.ParseSize(tc.input)
+		if tc.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tc.err) {
+				t.Errorf("ParseSize(%q): got (%v, %v), want error %q", tc.input, got, err, tc.err)
+			}
+		} else if err != nil {
+			t.Errorf("ParseSize(%q): unexpected error: %v", tc.input, err)
+		} else if got != tc.want {
+			t.Errorf("ParseSize(%q): got %d, want %d", tc.input, got, tc.want)
+		}
+	}
+}
+
+func TestSizeString(t *testing.T) {
+	tests := []struct {
+		input flax.Size
+		want  string
+	}{
+		{0, "0"},
+		{1, "1"},
+		{1023, "1023"},
+		{1024, "1KiB"},
+		{1000, "1KB"},
+		{1536, "1536"},
+		{64 * flax.KiB, "64KiB"},
+		{10 * flax.MB, "10MB"},
+		{flax.GiB, "1GiB"},
+		{1500 * flax.MB, "1500MB"},
+		{-2 * flax.KiB, "-2KiB"},
+		{math.MaxInt64, "9223372036854775807"},
+	}
+	for _, tc := range tests {
+		if got := tc.input.String(); got != tc.want {
+			t.Errorf("Size(%d).String(): got %q, want %q", tc.input, got, tc.want)
+		}
+		if tc.input >= 0 {
+			if back, err := flax.ParseSize(tc.want); err != nil || back != tc.input {
+				t.Errorf("ParseSize(%q): got (%v, %v), want %d", tc.want, back, err, tc.input)
+			}
+		}
+	}
+}
+
+func TestSizeFlags(t *testing.T) {
+	var flags struct {
+		Buffer flax.Size `flag:"buffer,default=64KiB,Buffer size"`
+		Limit  int64     `flag:"limit,size,default=1GB,Limit"`
+		Max    uint64    `flag:"max,size,Maximum"`
+		Count  int64     `flag:"count,Plain count"`
+	}
+	fi, err := flax.Check(&flags)
+	if err != nil {
+		t.Fatalf("Check failed: %v", err)
+	}
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	fi.Bind(fs)
+
+	if flags.Buffer != 64*flax.KiB || flags.Limit != 1e9 {
+		t.Errorf("Defaults: got %+v", flags)
+	}
+	if err := fs.Parse([]string{"-buffer", "1MiB", "-limit", "2.5KiB", "-max", "15EiB", "-count", "5"}); err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if flags.Buffer != flax.MiB || flags.Limit != 2560 || flags.Max != 15<<60 || flags.Count != 5 {
+		t.Errorf("Parse: got %+v", flags)
+	}
+	if got := fi.Flag("limit").Value().String(); got != "2560" {
+		t.Errorf("Limit value: got %q, want 2560", got)
+	}
+	for _, arg := range []string{"-limit=8EiB", "-max=16EiB", "-count=1KB", "-buffer=lots"} {
+		if err := fs.Parse([]string{arg}); err == nil {
+			t.Errorf("Parse %q: got nil, want error", arg)
+		}
+	}
+
+	var out strings.Builder
+	fs.SetOutput(&out)
+	fs.PrintDefaults()
+	usage := out.String()
+	for _, want := range []string{"(default 64KiB)", "(default 1GB)"} {
+		if !strings.Contains(usage, want) {
+			t.Errorf("Usage: missing %q:\n%s", want, usage)
+		}
+	}
+	if strings.Contains(usage, "(default 0)") {
+		t.Errorf("Usage: unexpected zero default:\n%s", usage)
+	}
+
+	var bad struct {
+		S string `flag:"s,size,Not an integer"`
+	}
+	if _, err := flax.Check(&bad); err == nil || !strings.Contains(err.Error(), "size option") {
+		t.Errorf("Check: got %v, want size option error", err)
+	}
+}
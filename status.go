@@ -0,0 +1,79 @@
+// Copyright (C) 2023 Michael J. Fromberger. All Rights Reserved.
+
+package flax
+
+import "flag"
+
+// IsSet reports whether the flag for fi has been set in the flag set to which
+// it was most recently bound, either by the arguments or from the
+// environment. It reports false if fi has not been bound. A field that was
+// not bound itself is treated as bound to the flag of the same name in the
+// flag set passed to [Fields.Parse], if there is one; see [NewFields].
+func (fi *Field) IsSet() bool {
+	if fi.flag == nil {
+		return false
+	}
+	set := false
+	fi.flagSet.Visit(func(f *flag.Flag) {
+		if f == fi.flag || f == fi.noFlag {
+			set = true
+		}
+	})
+	return set
+}
+
+// adopt associates each field of f that has not been bound with the flag of
+// the same name in fs, if any, as if the field had been bound to it. This lets
+// fields report the state of flags bound by other means, such as the code
+// generated by flaxgen.
+func (f Fields) adopt(fs *flag.FlagSet) {
+	for _, fi := range f {
+		if fi.flag != nil {
+			continue
+		}
+		if fl := fs.Lookup(fi.Name); fl != nil {
+			fi.flag, fi.flagSet = fl, fs
+			if fi.isNegatable() {
+				fi.noFlag = fs.Lookup(negatedPrefix + fi.Name)
+			}
+		}
+	}
+}
+
+// IsDefault reports whether the current value of fi is equal to its default
+// value, as resolved when fi was constructed. Values are compared by their
+// string representations.
+func (fi *Field) IsDefault() bool { return fi.current() == fi.dtext }
+
+// IsSet reports whether f contains a field with the given flag name whose
+// flag has been set. See [Field.IsSet].
+func (f Fields) IsSet(name string) bool {
+	fi := f.Flag(name)
+	return fi != nil && fi.IsSet()
+}
+
+// Changed returns the fields of f whose flags were set by the arguments when
+// the flags were parsed, in order. Fields set from the environment by
+// [Fields.Parse] are not included, nor are fields that have not been bound to
+// a flag set.
+func (f Fields) Changed() Fields {
+	var out Fields
+	for _, fi := range f {
+		if fi.IsSet() && !fi.fromEnv {
+			out = append(out, fi)
+		}
+	}
+	return out
+}
+
+// NonDefault returns the fields of f whose current values differ from their
+// default values, in order. See [Field.IsDefault].
+func (f Fields) NonDefault() Fields {
+	var out Fields
+	for _, fi := range f {
+		if !fi.IsDefault() {
+			out = append(out, fi)
+		}
+	}
+	return out
+}
@@ -0,0 +1,78 @@
+// Copyright (C) 2023 Michael J. Fromberger. All Rights Reserved.
+
+package flax_test
+
+import (
+	"flag"
+	"reflect"
+	"testing"
+
+	"github.com/creachadair/flax"
+)
+
+func fieldNames(fs flax.Fields) []string {
+	var names []string
+	for _, fi := range fs {
+		names = append(names, fi.Name)
+	}
+	return names
+}
+
+func TestChanged(t *testing.T) {
+	t.Setenv("TEST_STATUS_LEVEL", "3")
+
+	var flags struct {
+		Verbose bool   `flag:"v,Verbose"`
+		Name    string `flag:"name,default=anon,Name"`
+		Level   int    `flag:"level,env=TEST_STATUS_LEVEL,Level"`
+		Count   int    `flag:"count,default=2,Count"`
+		Tag     string `flag:"tag,Tag"`
+	}
+	fi := flax.MustCheck(&flags)
+
+	if fi.IsSet("v") {
+		t.Error("IsSet(v) before Bind: got true, want false")
+	}
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fi.Bind(fs)
+	if got := fieldNames(fi.NonDefault()); got != nil {
+		t.Errorf("NonDefault before Parse: got %q, want none", got)
+	}
+	if err := fi.Parse(fs, []string{"-v", "-name", "anon", "-count", "5"}); err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	for _, tc := range []struct {
+		name string
+		want bool
+	}{
+		{"v", true}, {"name", true}, {"level", true}, {"count", true},
+		{"tag", false}, {"nonesuch", false},
+	} {
+		if got := fi.IsSet(tc.name); got != tc.want {
+			t.Errorf("IsSet(%q): got %v, want %v", tc.name, got, tc.want)
+		}
+	}
+	if got, want := fieldNames(fi.Changed()), []string{"v", "name", "count"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Changed: got %q, want %q", got, want)
+	}
+	if got, want := fieldNames(fi.NonDefault()), []string{"v", "level", "count"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("NonDefault: got %q, want %q", got, want)
+	}
+
+	fi.Reset()
+	if got := fieldNames(fi.NonDefault()); got != nil {
+		t.Errorf("NonDefault after Reset: got %q, want none", got)
+	}
+
+	// Rebinding to a new flag set starts over.
+	fs2 := flag.NewFlagSet("test2", flag.ContinueOnError)
+	fi.Bind(fs2)
+	if fi.IsSet("v") {
+		t.Error("IsSet(v) after rebind: got true, want false")
+	}
+	if got := fieldNames(fi.Changed()); got != nil {
+		t.Errorf("Changed after rebind: got %q, want none", got)
+	}
+}
@@ -0,0 +1,154 @@
+// Copyright (C) 2023 Michael J. Fromberger. All Rights Reserved.
+
+package flax
+
+import (
+	"fmt"
+	"log/slog"
+	"net"
+	"net/netip"
+	"net/url"
+	"os"
+	"reflect"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+// stdTypes maps standard library types that are not otherwise flag
+// compatible, or whose default handling is awkward, to functions that return
+// codecs for them given the tag options of a field. For the types whose zero
+// value is not meaningful as a flag, the zero value is rendered as "" and an
+// empty string parses to the zero value.
+var stdTypes = map[reflect.Type]func(opts map[string]string) (*typeCodec, error){
+	reflect.TypeFor[time.Time](): timeCodec,
+	reflect.TypeFor[time.Duration](): func(opts map[string]string) (*typeCodec, error) {
+		return durationCodec(opts, false)
+	},
+	reflect.TypeFor[Duration](): func(opts map[string]string) (*typeCodec, error) {
+		return durationCodec(opts, true)
+	},
+	reflect.TypeFor[*time.Location](): codecFor(func(s string) (*time.Location, error) {
+		if s == "" {
+			return nil, nil
+		}
+		return time.LoadLocation(s)
+	}, formatString[*time.Location]),
+	reflect.TypeFor[net.IP](): codecFor(func(s string) (net.IP, error) {
+		if s == "" {
+			return nil, nil
+		} else if ip := net.ParseIP(s); ip != nil {
+			return ip, nil
+		}
+		return nil, fmt.Errorf("invalid IP address %q", s)
+	}, func(ip net.IP) string {
+		if ip == nil {
+			return ""
+		}
+		return ip.String()
+	}),
+	reflect.TypeFor[netip.Addr](): codecFor(parseZero(netip.ParseAddr), func(a netip.Addr) string {
+		if !a.IsValid() {
+			return ""
+		}
+		return a.String()
+	}),
+	reflect.TypeFor[netip.AddrPort](): codecFor(parseZero(netip.ParseAddrPort), func(a netip.AddrPort) string {
+		if !a.IsValid() {
+			return ""
+		}
+		return a.String()
+	}),
+	reflect.TypeFor[*url.URL]():       codecFor(parseZero(url.Parse), formatString[*url.URL]),
+	reflect.TypeFor[*regexp.Regexp](): codecFor(parseZero(regexp.Compile), formatString[*regexp.Regexp]),
+	reflect.TypeFor[os.FileMode](): codecFor(func(s string) (os.FileMode, error) {
+		m, err := strconv.ParseUint(s, 8, 32)
+		if err != nil {
+			return 0, fmt.Errorf("invalid octal file mode %q", s)
+		}
+		return os.FileMode(m), nil
+	}, func(m os.FileMode) string {
+		return "0" + strconv.FormatUint(uint64(m), 8)
+	}),
+	reflect.TypeFor[slog.Level](): codecFor(func(s string) (slog.Level, error) {
+		var lvl slog.Level
+		err := lvl.UnmarshalText([]byte(s))
+		return lvl, err
+	}, slog.Level.String),
+}
+
+// codecFor returns a stdTypes entry that ignores the tag options.
+func codecFor[T any](parse func(string) (T, error), format func(T) string) func(map[string]string) (*typeCodec, error) {
+	c := newCodec(parse, format)
+	return func(map[string]string) (*typeCodec, error) { return c, nil }
+}
+
+// parseZero wraps parse so that an empty string parses as the zero value.
+func parseZero[T any](parse func(string) (T, error)) func(string) (T, error) {
+	return func(s string) (T, error) {
+		if s == "" {
+			var zero T
+			return zero, nil
+		}
+		return parse(s)
+	}
+}
+
+// formatString formats a nillable value using its String method, rendering
+// nil as "".
+func formatString[T interface {
+	comparable
+	fmt.Stringer
+}](v T) string {
+	var zero T
+	if v == zero {
+		return ""
+	}
+	return v.String()
+}
+
+// timeLayouts maps the names of the layout constants defined by the time
+// package to their values, for use in the layout option.
+var timeLayouts = map[string]string{
+	"Layout":      time.Layout,
+	"ANSIC":       time.ANSIC,
+	"UnixDate":    time.UnixDate,
+	"RubyDate":    time.RubyDate,
+	"RFC822":      time.RFC822,
+	"RFC822Z":     time.RFC822Z,
+	"RFC850":      time.RFC850,
+	"RFC1123":     time.RFC1123,
+	"RFC1123Z":    time.RFC1123Z,
+	"RFC3339":     time.RFC3339,
+	"RFC3339Nano": time.RFC3339Nano,
+	"Kitchen":     time.Kitchen,
+	"Stamp":       time.Stamp,
+	"StampMilli":  time.StampMilli,
+	"StampMicro":  time.StampMicro,
+	"StampNano":   time.StampNano,
+	"DateTime":    time.DateTime,
+	"DateOnly":    time.DateOnly,
+	"TimeOnly":    time.TimeOnly,
+}
+
+// timeCodec returns a codec for time.Time using the layout given by the
+// layout option, which may be a literal layout string or the name of one of
+// the layout constants of the time package. The default is RFC3339.
+func timeCodec(opts map[string]string) (*typeCodec, error) {
+	layout, ok := opts["layout"]
+	if !ok {
+		layout = time.RFC3339
+	} else if named, ok := timeLayouts[layout]; ok {
+		layout = named
+	} else if layout == "" {
+		return nil, fmt.Errorf("empty time layout")
+	}
+	return newCodec(parseZero(func(s string) (time.Time, error) {
+		return time.Parse(layout, s)
+	}), func(t time.Time) string {
+		if t.IsZero() {
+			return ""
+		}
+		return t.Format(layout)
+	}), nil
+}
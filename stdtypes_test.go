@@ -0,0 +1,137 @@
+// Copyright (C) 2023 Michael J. Fromberger. All Rights Reserved.
+
+package flax_test
+
+import (
+	"flag"
+	"io"
+	"log/slog"
+	"net"
+	"net/netip"
+	"net/url"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/creachadair/flax"
+	"github.com/creachadair/flax/internal/tagcheck"
+)
+
+func TestStandardTypes(t *testing.T) {
+	var flags struct {
+		When   time.Time      `flag:"when,default=2023-01-02T03:04:05Z,When"`
+		Day    time.Time      `flag:"day,layout=DateOnly,Day"`
+		Clock  time.Time      `flag:"clock,layout=15h04,Clock"`
+		Zone   *time.Location `flag:"zone,default=UTC,Zone"`
+		IP     net.IP         `flag:"ip,IP address"`
+		Addr   netip.Addr     `flag:"addr,default=::1,Address"`
+		Listen netip.AddrPort `flag:"listen,Listen address"`
+		URL    *url.URL       `flag:"url,URL"`
+		Match  *regexp.Regexp `flag:"match,default=^a+$,Pattern"`
+		Mode   os.FileMode    `flag:"mode,default=0644,Mode"`
+		Level  slog.Level     `flag:"level,default=warn,Level"`
+	}
+	fi, err := flax.Check(&flags)
+	if err != nil {
+		t.Fatalf("Check failed: %v", err)
+	}
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	fi.Bind(fs)
+
+	defaults := map[string]string{
+		"when": "2023-01-02T03:04:05Z", "day": "", "clock": "", "zone": "UTC",
+		"ip": "", "addr": "::1", "listen": "", "url": "", "match": "^a+$",
+		"mode": "0644", "level": "WARN",
+	}
+	for name, want := range defaults {
+		if got := fi.Flag(name).Default(); got != want {
+			t.Errorf("Default %q: got %q, want %q", name, got, want)
+		}
+	}
+	if flags.Zone != time.UTC || !flags.Match.MatchString("aaa") || flags.Mode != 0644 || flags.Level != slog.LevelWarn {
+		t.Errorf("Defaults not applied: %+v", flags)
+	}
+
+	if err := fs.Parse([]string{
+		"-day", "2024-02-29", "-clock", "13h30", "-zone", "America/New_York",
+		"-ip", "10.0.0.1", "-listen", "127.0.0.1:8080", "-url", "https://example.com/x?y=1",
+		"-match", "b", "-mode", "755", "-level", "debug+2", "-addr", "",
+	}); err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	values := map[string]string{
+		"day": "2024-02-29", "clock": "13h30", "zone": "America/New_York",
+		"ip": "10.0.0.1", "addr": "", "listen": "127.0.0.1:8080",
+		"url": "https://example.com/x?y=1", "match": "b", "mode": "0755", "level": "DEBUG+2",
+	}
+	for name, want := range values {
+		if got := fi.Flag(name).Value().String(); got != want {
+			t.Errorf("Value %q: got %q, want %q", name, got, want)
+		}
+	}
+	if got, want := flags.Day, time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("Day: got %v, want %v", got, want)
+	}
+	if flags.Addr.IsValid() {
+		t.Errorf("Addr: got %v, want zero", flags.Addr)
+	}
+	if got := fi.Flag("url").Value().Get(); got != flags.URL {
+		t.Errorf("Get url: got %v, want %v", got, flags.URL)
+	}
+
+	for _, arg := range []string{
+		"-when=yesterday", "-day=2024-13-01", "-zone=Nowhere/Special", "-ip=1.2.3",
+		"-addr=bogus", "-listen=1.2.3.4", "-url=:x", "-match=(", "-mode=9", "-level=loud",
+	} {
+		if err := fs.Parse([]string{arg}); err == nil {
+			t.Errorf("Parse %q: got nil, want error", arg)
+		}
+	}
+
+	fi.Reset()
+	if flags.Zone != time.UTC || flags.IP != nil || flags.Mode != 0644 || !flags.Day.IsZero() {
+		t.Errorf("Reset: got %+v", flags)
+	}
+}
+
+func TestLayoutOption(t *testing.T) {
+	var good struct {
+		T time.Time `flag:"t,layout='Mon, 02 Jan',default='Tue, 03 Jan',Time"`
+	}
+	if _, err := flax.Check(&good); err != nil {
+		t.Errorf("Check: unexpected error: %v", err)
+	}
+
+	for _, tc := range []struct {
+		v    any
+		want string
+	}{
+		{&struct {
+			S string `flag:"s,layout=DateOnly,Not a time"`
+		}{}, "layout option requires a field of type time.Time"},
+		{&struct {
+			T time.Time `flag:"t,layout=,Empty"`
+		}{}, "empty time layout"},
+		{&struct {
+			T time.Time `flag:"t,layout=DateOnly,default=2023,Bad default"`
+		}{}, "invalid default"},
+	} {
+		if _, err := flax.Check(tc.v); err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("Check %T: got %v, want %q", tc.v, err, tc.want)
+		}
+	}
+}
+
+func TestStandardTypesChecked(t *testing.T) {
+	// The static checker used by flaxgen and flaxlint must accept all the
+	// standard library types that Check does.
+	for _, rt := range flax.StdTypes() {
+		if !slices.Contains(tagcheck.StdTypes, rt) {
+			t.Errorf("Type %v is missing from tagcheck.StdTypes", rt)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+// Copyright (C) 2023 Michael J. Fromberger. All Rights Reserved.
+
+package flax
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+)
+
+// A Validator is a value that can check its own validity. If the struct
+// passed to [Check], or the type of any of its flag fields, implements
+// Validator, its Validate method is called by [Fields.Validate].
+type Validator interface {
+	Validate() error
+}
+
+// Validate calls the Validate method of each field of f whose type implements
+// [Validator], in order, and then of each distinct struct from which the
+// fields of f were constructed by [Check] that implements Validator. It
+// returns an error combining all the errors reported; an error from a field
+// is annotated with its flag name. [Fields.Parse] calls Validate after
+// parsing.
+func (f Fields) Validate() error {
+	var errs []error
+	for _, fi := range f {
+		if err := validateValue(fi.targetValue()); err != nil {
+			errs = append(errs, fmt.Errorf("flag -%s: %w", fi.Name, err))
+		}
+	}
+	var seen []any
+	for _, fi := range f {
+		if fi.owner == nil || slices.Contains(seen, fi.owner) {
+			continue
+		}
+		seen = append(seen, fi.owner)
+		if v, ok := fi.owner.(Validator); ok {
+			if err := v.Validate(); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// validateValue calls the Validate method of v, if it has one. If v is a
+// non-nil pointer, the value it points to is also considered.
+func validateValue(v reflect.Value) error {
+	if vv, ok := v.Addr().Interface().(Validator); ok {
+		return vv.Validate()
+	} else if v.Kind() == reflect.Pointer && !v.IsNil() {
+		if vv, ok := v.Interface().(Validator); ok {
+			return vv.Validate()
+		}
+	}
+	return nil
+}
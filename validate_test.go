@@ -0,0 +1,147 @@
+// Copyright (C) 2023 Michael J. Fromberger. All Rights Reserved.
+
+package flax_test
+
+import (
+	"errors"
+	"flag"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/creachadair/flax"
+)
+
+// port is a flag type whose values are checked by a Validate method.
+type port int
+
+func (p port) String() string { return strconv.Itoa(int(p)) }
+
+func (p *port) Set(s string) error {
+	v, err := strconv.Atoi(s)
+	*p = port(v)
+	return err
+}
+
+func (p port) Validate() error {
+	if p < 0 || p > 65535 {
+		return errors.New("port out of range")
+	}
+	return nil
+}
+
+// evenCount checks its value with a pointer method.
+type evenCount struct{ flax.Size }
+
+func (e *evenCount) Validate() error {
+	if e.Size%2 != 0 {
+		return errors.New("odd count")
+	}
+	return nil
+}
+
+type rangeFlags struct {
+	Port  port      `flag:"port,default=8080,Port"`
+	Count evenCount `flag:"count,reloadable,Count"`
+	Min   int       `flag:"min,reloadable,Minimum"`
+	Max   int       `flag:"max,reloadable,default=10,Maximum"`
+}
+
+func (s *rangeFlags) Validate() error {
+	if s.Min > s.Max {
+		return errors.New("min exceeds max")
+	}
+	return nil
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		args []string
+		want []string // error substrings; nil for success
+	}{
+		{nil, nil},
+		{[]string{"-port", "443", "-count", "4", "-min", "3"}, nil},
+		{[]string{"-port", "70000"}, []string{"flag -port: port out of range"}},
+		{[]string{"-count", "3"}, []string{"flag -count: odd count"}},
+		{[]string{"-min", "11"}, []string{"min exceeds max"}},
+		{[]string{"-port", "-1", "-count", "1", "-max", "-1"}, []string{
+			"flag -port: port out of range", "flag -count: odd count", "min exceeds max",
+		}},
+	}
+	for _, tc := range tests {
+		var flags rangeFlags
+		fi, err := flax.Check(&flags)
+		if err != nil {
+			t.Fatalf("Check failed: %v", err)
+		}
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		fs.SetOutput(io.Discard)
+		fi.Bind(fs)
+		err = fi.Parse(fs, tc.args)
+		if tc.want == nil {
+			if err != nil {
+				t.Errorf("Parse %q: unexpected error: %v", tc.args, err)
+			}
+			continue
+		} else if err == nil {
+			t.Errorf("Parse %q: got nil, want error", tc.args)
+			continue
+		}
+		if got := strings.Split(err.Error(), "\n"); len(got) != len(tc.want) {
+			t.Errorf("Parse %q: got %d errors, want %d: %v", tc.args, len(got), len(tc.want), err)
+		}
+		for _, want := range tc.want {
+			if !strings.Contains(err.Error(), want) {
+				t.Errorf("Parse %q: got %v, want %q", tc.args, err, want)
+			}
+		}
+	}
+}
+
+func TestValidateNewFields(t *testing.T) {
+	var flags rangeFlags
+	fi, err := flax.NewFields(&flags,
+		flax.FieldSpec{Field: "Min", Name: "min", Usage: "Minimum"},
+		flax.FieldSpec{Field: "Max", Name: "max", Usage: "Maximum", Options: map[string]string{"default": "10"}},
+	)
+	if err != nil {
+		t.Fatalf("NewFields failed: %v", err)
+	}
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	fi.Bind(fs)
+	if err := fi.Parse(fs, []string{"-min", "11"}); err == nil || err.Error() != "min exceeds max" {
+		t.Errorf("Parse: got %v, want validation error", err)
+	}
+}
+
+func TestValidateLive(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config")
+	writeConfig(t, path, "-min 5\n")
+	lv, err := flax.NewLive[rangeFlags](path)
+	if err != nil {
+		t.Fatalf("NewLive failed: %v", err)
+	}
+
+	writeConfig(t, path, "-min 20\n")
+	if err := lv.Reload(); err == nil || !strings.Contains(err.Error(), "min exceeds max") {
+		t.Errorf("Reload: got %v, want validation error", err)
+	}
+	if got := lv.Get().Min; got != 5 {
+		t.Errorf("Min after failed reload: got %d, want 5", got)
+	}
+
+	writeConfig(t, path, "-min 20\n-max 30\n")
+	if err := lv.Reload(); err != nil {
+		t.Errorf("Reload: unexpected error: %v", err)
+	} else if got := lv.Get(); got.Min != 20 || got.Max != 30 {
+		t.Errorf("Reload: got %+v, want min 20, max 30", got)
+	}
+
+	writeConfig(t, path, "-count 7\n")
+	if _, err := flax.NewLive[rangeFlags](path); err == nil {
+		t.Error("NewLive: got nil, want validation error")
+	}
+}
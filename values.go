@@ -0,0 +1,79 @@
+// Copyright (C) 2023 Michael J. Fromberger. All Rights Reserved.
+
+package flax
+
+import (
+	"encoding/json"
+	"log/slog"
+	"time"
+)
+
+// Redacted is the text reported in place of the value of a field whose tag
+// has the secret option.
+const Redacted = "[redacted]"
+
+// Values is a view of the current values of a collection of fields.
+//
+// Values implements [slog.LogValuer], so that it can be logged as a group of
+// name/value pairs, and has a String method that renders the values as a
+// JSON object, so that it can be published as an [expvar.Var]:
+//
+//	expvar.Publish("flags", flax.Values{Fields: fields})
+//
+// The values of fields tagged with the secret option are redacted. Fields of
+// type bool, float64, int, int64, string, uint, and uint64 are reported with
+// their natural types; other fields are reported as strings.
+type Values struct {
+	Fields Fields
+
+	// If true, omit fields whose current value is equal to their default.
+	OmitDefaults bool
+}
+
+// LogValue implements the [slog.LogValuer] interface.
+func (v Values) LogValue() slog.Value {
+	var attrs []slog.Attr
+	v.each(func(fi *Field, val any) {
+		attrs = append(attrs, slog.Any(fi.Name, val))
+	})
+	return slog.GroupValue(attrs...)
+}
+
+// String renders the values of v as a JSON object mapping flag names to
+// values. It implements the String method of the [expvar.Var] interface.
+func (v Values) String() string {
+	obj := make(map[string]any)
+	v.each(func(fi *Field, val any) { obj[fi.Name] = val })
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return "{}" // should not be possible
+	}
+	return string(data)
+}
+
+// each calls f for each field of v that should be reported, with its value.
+func (v Values) each(f func(*Field, any)) {
+	for _, fi := range v.Fields {
+		if v.OmitDefaults && fi.IsDefault() {
+			continue
+		}
+		if _, ok := fi.opts["secret"]; ok {
+			f(fi, Redacted)
+			continue
+		}
+		switch t := fi.target.(type) {
+		case *bool, *float64, *int, *int64, *string, *uint, *uint64:
+			f(fi, fi.get())
+		case *time.Duration:
+			f(fi, t.String())
+		default:
+			f(fi, fi.current())
+		}
+	}
+}
+
+// LogValue implements the [slog.LogValuer] interface, reporting the current
+// values of the fields of f as a group. It is equivalent to:
+//
+//	flax.Values{Fields: f}.LogValue()
+func (f Fields) LogValue() slog.Value { return Values{Fields: f}.LogValue() }
@@ -0,0 +1,100 @@
+// Copyright (C) 2023 Michael J. Fromberger. All Rights Reserved.
+
+package flax_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/creachadair/flax"
+)
+
+type valueFlags struct {
+	Addr    string        `flag:"addr,default=:8080,Service address"`
+	Level   int           `flag:"level,default=1,Log level"`
+	Debug   bool          `flag:"debug,Debug mode"`
+	Timeout time.Duration `flag:"timeout,default=5s,Timeout"`
+	Token   string        `flag:"token,secret,Access token"`
+	Text    textFlag      `flag:"text,Text value"`
+}
+
+func newValueFlags(t *testing.T) (*valueFlags, flax.Fields) {
+	t.Helper()
+	var v valueFlags
+	fields, err := flax.Check(&v)
+	if err != nil {
+		t.Fatalf("Check failed: %v", err)
+	}
+	fields.Reset()
+	return &v, fields
+}
+
+func TestValuesString(t *testing.T) {
+	v, fields := newValueFlags(t)
+	v.Level = 3
+	v.Token = "hunter2"
+	v.Text.value = "hello"
+
+	var got map[string]any
+	if err := json.Unmarshal([]byte(flax.Values{Fields: fields}.String()), &got); err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	want := map[string]any{
+		"addr":    ":8080",
+		"level":   3.0,
+		"debug":   false,
+		"timeout": "5s",
+		"token":   flax.Redacted,
+		"text":    "hello",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Values:\ngot:  %v\nwant: %v", got, want)
+	}
+
+	got = nil
+	if err := json.Unmarshal([]byte(flax.Values{Fields: fields, OmitDefaults: true}.String()), &got); err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	want = map[string]any{"level": 3.0, "token": flax.Redacted, "text": "hello"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Non-default values:\ngot:  %v\nwant: %v", got, want)
+	}
+}
+
+func TestLogValue(t *testing.T) {
+	v, fields := newValueFlags(t)
+	v.Debug = true
+	v.Token = "hunter2"
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{
+		ReplaceAttr: func(_ []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.TimeKey {
+				return slog.Attr{}
+			}
+			return a
+		},
+	}))
+	logger.Info("config", "flags", fields)
+	logger.Info("changed", "flags", flax.Values{Fields: fields, OmitDefaults: true})
+
+	dec := json.NewDecoder(&buf)
+	for _, want := range []map[string]any{
+		{"addr": ":8080", "level": 1.0, "debug": true, "timeout": "5s", "token": flax.Redacted, "text": ""},
+		{"debug": true, "token": flax.Redacted},
+	} {
+		var rec struct {
+			Flags map[string]any `json:"flags"`
+		}
+		if err := dec.Decode(&rec); err != nil {
+			t.Fatalf("Decode: %v", err)
+		}
+		if !reflect.DeepEqual(rec.Flags, want) {
+			t.Errorf("Logged flags:\ngot:  %v\nwant: %v", rec.Flags, want)
+		}
+	}
+}